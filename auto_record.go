@@ -0,0 +1,118 @@
+package recmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf8"
+
+	"github.com/samber/lo"
+)
+
+// AutoChunk is a chunk of an AutoRecord.
+// It is encoded as json string if it is valid UTF-8 and as {"base64": "..."} otherwise,
+// so every chunk round-trips byte for byte.
+type AutoChunk []byte
+
+type autoBinaryChunk struct {
+	Base64 []byte `json:"base64"`
+}
+
+func (c AutoChunk) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(c) {
+		return json.Marshal(string(c))
+	}
+	return json.Marshal(autoBinaryChunk{Base64: c})
+}
+
+func (c *AutoChunk) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*c = AutoChunk(text)
+		return nil
+	}
+
+	var binary autoBinaryChunk
+	if err := json.Unmarshal(data, &binary); err != nil {
+		return fmt.Errorf("chunk is neither a string nor {\"base64\": \"...\"}: %s", data)
+	}
+	*c = AutoChunk(binary.Base64)
+	return nil
+}
+
+type AutoRecord struct {
+	JsonFormat RecordFormat `json:"format"`
+
+	Cmd   string                      `json:"command"`
+	Out   map[time.Duration]AutoChunk `json:"out"`
+	In    map[time.Duration]AutoChunk `json:"in"`
+	Err   map[time.Duration]AutoChunk `json:"err"`
+	ExitC int                         `json:"exitcode"`
+	Marks []Marker                    `json:"markers,omitempty"`
+	Meta  map[string]string           `json:"metadata,omitempty"`
+}
+
+func (ar *AutoRecord) Reader() io.Reader {
+	return NewReader(ar)
+}
+
+func (ar *AutoRecord) StdOut() map[time.Duration][]byte {
+	return lo.MapValues[time.Duration, AutoChunk, []byte](ar.Out, func(value AutoChunk, _ time.Duration) []byte {
+		return value
+	})
+}
+
+func (ar *AutoRecord) StdIn() map[time.Duration][]byte {
+	return lo.MapValues[time.Duration, AutoChunk, []byte](ar.In, func(value AutoChunk, _ time.Duration) []byte {
+		return value
+	})
+}
+
+func (ar *AutoRecord) StdErr() map[time.Duration][]byte {
+	return lo.MapValues[time.Duration, AutoChunk, []byte](ar.Err, func(value AutoChunk, _ time.Duration) []byte {
+		return value
+	})
+}
+
+func (ar *AutoRecord) Command() string {
+	return ar.Cmd
+}
+
+func (ar *AutoRecord) ExitCode() int {
+	return ar.ExitC
+}
+
+// Markers returns the markers of the record sorted by offset.
+func (ar *AutoRecord) Markers() []Marker {
+	return ar.Marks
+}
+
+// AddMarker adds a marker to the record, keeping the markers sorted by offset.
+func (ar *AutoRecord) AddMarker(marker Marker) {
+	ar.Marks = insertMarker(ar.Marks, marker)
+}
+
+// Metadata returns the metadata of the record, may be nil.
+func (ar *AutoRecord) Metadata() map[string]string {
+	return ar.Meta
+}
+
+// SetMetadata sets a metadata entry of the record.
+func (ar *AutoRecord) SetMetadata(key string, value string) {
+	if ar.Meta == nil {
+		ar.Meta = make(map[string]string)
+	}
+	ar.Meta[key] = value
+}
+
+func (ar *AutoRecord) Format() RecordFormat {
+	if ar.JsonFormat == "" {
+		ar.JsonFormat = FormatAuto
+	}
+	return ar.JsonFormat
+}
+
+func (ar *AutoRecord) ConvertTo(format RecordFormat) (Record, error) {
+	return convertRecord(ar, format)
+}
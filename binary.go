@@ -0,0 +1,493 @@
+package recmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+	"time"
+)
+
+// Layout of a binary record:
+//
+//	magic "RECMDBIN" | version byte
+//	blocks:  uint32 payload length | payload | uint32 crc32 of payload
+//	         the payload of an event block is a sequence of frames:
+//	         uvarint frame length | stream byte | uvarint offset | data
+//	footer:  a block holding command, exit code, metadata, markers and the index
+//	         of the first offset and file position of every event block
+//	trailer: uint64 file position of the footer | "RECMDEND"
+//
+// All integers are little endian.
+var (
+	binaryMagic   = []byte("RECMDBIN")
+	binaryTrailer = []byte("RECMDEND")
+)
+
+const binaryVersion = 1
+
+// Events are split into blocks at these boundaries, which makes the index granularity.
+const (
+	binaryBlockInterval = time.Second
+	binaryBlockSize     = 64 * 1024
+)
+
+var binaryStreams = []Stream{StreamOut, StreamIn, StreamErr}
+
+// ErrChecksum is returned when a block of a binary record is corrupted.
+var ErrChecksum = errors.New("binary: checksum mismatch")
+
+// IndexEntry points to a block of a binary record.
+type IndexEntry struct {
+	// Offset is the offset of the first event in the block.
+	Offset   time.Duration
+	Position int64
+}
+
+// EncodeBinary writes the record as indexed binary record to w.
+func EncodeBinary(w io.Writer, record Record) error {
+	writer := &countingWriter{w: w}
+
+	_, err := writer.Write(append(append([]byte{}, binaryMagic...), binaryVersion))
+	if err != nil {
+		return err
+	}
+
+	var index []IndexEntry
+	var block []byte
+	var blockStart time.Duration
+
+	flush := func() error {
+		if len(block) == 0 {
+			return nil
+		}
+		index = append(index, IndexEntry{Offset: blockStart, Position: writer.n})
+		err := writeBlock(writer, block)
+		block = block[:0]
+		return err
+	}
+
+	for _, event := range Events(record) {
+		if len(block) > 0 && (event.Offset-blockStart >= binaryBlockInterval || len(block) >= binaryBlockSize) {
+			err = flush()
+			if err != nil {
+				return err
+			}
+		}
+		if len(block) == 0 {
+			blockStart = event.Offset
+		}
+
+		frame := []byte{byte(streamOrder(event.Stream))}
+		frame = binary.AppendUvarint(frame, uint64(event.Offset))
+		frame = append(frame, event.Data...)
+
+		block = binary.AppendUvarint(block, uint64(len(frame)))
+		block = append(block, frame...)
+	}
+
+	err = flush()
+	if err != nil {
+		return err
+	}
+
+	footerPosition := writer.n
+	err = writeBlock(writer, encodeFooter(record, index))
+	if err != nil {
+		return err
+	}
+
+	trailer := binary.LittleEndian.AppendUint64(nil, uint64(footerPosition))
+	_, err = writer.Write(append(trailer, binaryTrailer...))
+	return err
+}
+
+func encodeFooter(record Record, index []IndexEntry) []byte {
+	var footer []byte
+	footer = appendString(footer, record.Command())
+	footer = binary.AppendVarint(footer, int64(record.ExitCode()))
+
+	metadata := record.Metadata()
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	footer = binary.AppendUvarint(footer, uint64(len(keys)))
+	for _, key := range keys {
+		footer = appendString(footer, key)
+		footer = appendString(footer, metadata[key])
+	}
+
+	footer = binary.AppendUvarint(footer, uint64(len(record.Markers())))
+	for _, marker := range record.Markers() {
+		footer = binary.AppendUvarint(footer, uint64(marker.Offset))
+		footer = appendString(footer, marker.Label)
+	}
+
+	footer = binary.AppendUvarint(footer, uint64(len(index)))
+	for _, entry := range index {
+		footer = binary.AppendUvarint(footer, uint64(entry.Offset))
+		footer = binary.AppendUvarint(footer, uint64(entry.Position))
+	}
+
+	return footer
+}
+
+func writeBlock(w io.Writer, payload []byte) error {
+	block := binary.LittleEndian.AppendUint32(nil, uint32(len(payload)))
+	block = append(block, payload...)
+	block = binary.LittleEndian.AppendUint32(block, crc32.ChecksumIEEE(payload))
+	_, err := w.Write(block)
+	return err
+}
+
+func appendString(data []byte, value string) []byte {
+	data = binary.AppendUvarint(data, uint64(len(value)))
+	return append(data, value...)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// BinaryReader reads the events of a binary record, seeking to offsets with the help of its index.
+type BinaryReader struct {
+	r        io.ReadSeeker
+	command  string
+	exitCode int
+	metadata map[string]string
+	markers  []Marker
+	index    []IndexEntry
+
+	block     int
+	frames    []byte
+	position  time.Duration
+	seekedTo  time.Duration
+	hasSeeked bool
+}
+
+// NewBinaryReader reads the footer of the binary record in r and positions the reader at its start.
+func NewBinaryReader(r io.ReadSeeker) (*BinaryReader, error) {
+	header := make([]byte, len(binaryMagic)+1)
+	_, err := r.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.ReadFull(r, header)
+	if err != nil || !bytes.Equal(header[:len(binaryMagic)], binaryMagic) {
+		return nil, fmt.Errorf("binary: not a binary record")
+	}
+	if header[len(binaryMagic)] > binaryVersion {
+		return nil, fmt.Errorf("binary: unsupported version: %d", header[len(binaryMagic)])
+	}
+
+	trailer := make([]byte, 8+len(binaryTrailer))
+	_, err = r.Seek(-int64(len(trailer)), io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.ReadFull(r, trailer)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(trailer[8:], binaryTrailer) {
+		return nil, fmt.Errorf("binary: missing trailer, the record is incomplete")
+	}
+
+	footer, err := readBlock(r, int64(binary.LittleEndian.Uint64(trailer)))
+	if err != nil {
+		return nil, err
+	}
+
+	reader := &BinaryReader{r: r, block: -1}
+	err = reader.decodeFooter(footer)
+	if err != nil {
+		return nil, err
+	}
+
+	return reader, nil
+}
+
+func (br *BinaryReader) decodeFooter(footer []byte) error {
+	decoder := &binaryDecoder{data: footer}
+
+	br.command = decoder.string()
+	br.exitCode = int(decoder.varint())
+
+	metadataCount := decoder.uvarint()
+	for i := uint64(0); i < metadataCount && decoder.err == nil; i++ {
+		if br.metadata == nil {
+			br.metadata = make(map[string]string)
+		}
+		key := decoder.string()
+		br.metadata[key] = decoder.string()
+	}
+
+	markerCount := decoder.uvarint()
+	for i := uint64(0); i < markerCount && decoder.err == nil; i++ {
+		offset := time.Duration(decoder.uvarint())
+		br.markers = append(br.markers, Marker{Offset: offset, Label: decoder.string()})
+	}
+
+	indexCount := decoder.uvarint()
+	for i := uint64(0); i < indexCount && decoder.err == nil; i++ {
+		offset := time.Duration(decoder.uvarint())
+		br.index = append(br.index, IndexEntry{Offset: offset, Position: int64(decoder.uvarint())})
+	}
+
+	return decoder.err
+}
+
+func (br *BinaryReader) Command() string {
+	return br.command
+}
+
+func (br *BinaryReader) ExitCode() int {
+	return br.exitCode
+}
+
+func (br *BinaryReader) Metadata() map[string]string {
+	return br.metadata
+}
+
+func (br *BinaryReader) Markers() []Marker {
+	return br.markers
+}
+
+// Index returns the index of the blocks.
+func (br *BinaryReader) Index() []IndexEntry {
+	return br.index
+}
+
+// Seek moves the reader to a time offset in nanoseconds, relative to the start, the current position
+// or the offset of the last indexed block. Decoding starts at the last block starting before the offset, as blocks
+// split by size can end with events at the offset of the next block. It returns the new position.
+func (br *BinaryReader) Seek(offset int64, whence int) (int64, error) {
+	target := time.Duration(offset)
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		target += br.position
+	case io.SeekEnd:
+		if len(br.index) > 0 {
+			target += br.index[len(br.index)-1].Offset
+		}
+	default:
+		return 0, fmt.Errorf("binary: invalid whence: %d", whence)
+	}
+	if target < 0 {
+		return 0, fmt.Errorf("binary: negative position")
+	}
+
+	block := sort.Search(len(br.index), func(i int) bool {
+		return br.index[i].Offset >= target
+	}) - 1
+	if block < 0 {
+		block = 0
+	}
+
+	br.block = block - 1
+	br.frames = nil
+	br.position = target
+	br.seekedTo = target
+	br.hasSeeked = true
+
+	return int64(target), nil
+}
+
+// Next returns the next event, io.EOF after the last one.
+func (br *BinaryReader) Next() (Event, error) {
+	for {
+		for len(br.frames) == 0 {
+			br.block++
+			if br.block >= len(br.index) {
+				return Event{}, io.EOF
+			}
+			frames, err := readBlock(br.r, br.index[br.block].Position)
+			if err != nil {
+				return Event{}, err
+			}
+			br.frames = frames
+		}
+
+		event, err := br.nextFrame()
+		if err != nil {
+			return Event{}, err
+		}
+
+		if br.hasSeeked && event.Offset < br.seekedTo {
+			continue
+		}
+
+		br.position = event.Offset
+		return event, nil
+	}
+}
+
+func (br *BinaryReader) nextFrame() (Event, error) {
+	decoder := &binaryDecoder{data: br.frames}
+	frameLength := decoder.uvarint()
+	if decoder.err != nil || frameLength > uint64(len(decoder.data)) || frameLength == 0 {
+		return Event{}, fmt.Errorf("binary: invalid frame in block %d", br.block)
+	}
+	frame := &binaryDecoder{data: decoder.data[:frameLength]}
+	br.frames = decoder.data[frameLength:]
+
+	streamIndex := frame.byte()
+	offset := time.Duration(frame.uvarint())
+	if frame.err != nil || int(streamIndex) >= len(binaryStreams) {
+		return Event{}, fmt.Errorf("binary: invalid frame in block %d", br.block)
+	}
+
+	return Event{Offset: offset, Stream: binaryStreams[streamIndex], Data: frame.data}, nil
+}
+
+func readBlock(r io.ReadSeeker, position int64) ([]byte, error) {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if position < 0 || position > size-4 {
+		return nil, fmt.Errorf("binary: corrupt block position %d", position)
+	}
+
+	_, err = r.Seek(position, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(r)
+	length := make([]byte, 4)
+	_, err = io.ReadFull(reader, length)
+	if err != nil {
+		return nil, err
+	}
+
+	// the length is checked against the file before allocating, a corrupt one could claim up to 4 GiB
+	blockLength := int64(binary.LittleEndian.Uint32(length)) + 4
+	if blockLength > size-position-4 {
+		return nil, fmt.Errorf("binary: corrupt block length at position %d", position)
+	}
+
+	payload := make([]byte, blockLength)
+	_, err = io.ReadFull(reader, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	checksum := binary.LittleEndian.Uint32(payload[len(payload)-4:])
+	payload = payload[:len(payload)-4]
+	if crc32.ChecksumIEEE(payload) != checksum {
+		return nil, fmt.Errorf("%w in block at position %d", ErrChecksum, position)
+	}
+
+	return payload, nil
+}
+
+// DecodeBinary reads a whole binary record into a ByteRecord.
+func DecodeBinary(r io.ReadSeeker) (Record, error) {
+	reader, err := NewBinaryReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &ByteRecord{
+		JsonFormat: FormatBase64,
+		Cmd:        reader.Command(),
+		ExitC:      reader.ExitCode(),
+		Out:        make(map[time.Duration][]byte),
+		In:         make(map[time.Duration][]byte),
+		Err:        make(map[time.Duration][]byte),
+		Meta:       reader.Metadata(),
+		Marks:      reader.Markers(),
+	}
+	targets := map[Stream]map[time.Duration][]byte{
+		StreamOut: record.Out,
+		StreamIn:  record.In,
+		StreamErr: record.Err,
+	}
+
+	for {
+		event, err := reader.Next()
+		if err == io.EOF {
+			return record, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		appendChunk(targets[event.Stream], event.Offset, event.Data)
+	}
+}
+
+// binaryDecoder reads values from data, remembering the first error.
+type binaryDecoder struct {
+	data []byte
+	err  error
+}
+
+var errTruncated = errors.New("binary: truncated data")
+
+func (d *binaryDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	value, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.err = errTruncated
+		return 0
+	}
+	d.data = d.data[n:]
+	return value
+}
+
+func (d *binaryDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	value, n := binary.Varint(d.data)
+	if n <= 0 {
+		d.err = errTruncated
+		return 0
+	}
+	d.data = d.data[n:]
+	return value
+}
+
+func (d *binaryDecoder) byte() byte {
+	if d.err != nil {
+		return 0
+	}
+	if len(d.data) == 0 {
+		d.err = errTruncated
+		return 0
+	}
+	value := d.data[0]
+	d.data = d.data[1:]
+	return value
+}
+
+func (d *binaryDecoder) string() string {
+	length := d.uvarint()
+	if d.err != nil {
+		return ""
+	}
+	if length > uint64(len(d.data)) {
+		d.err = errTruncated
+		return ""
+	}
+	value := string(d.data[:length])
+	d.data = d.data[length:]
+	return value
+}
@@ -0,0 +1,119 @@
+package recmd
+
+import (
+	"io"
+	"sort"
+	"time"
+)
+
+// BinaryStreamReader replays a binary record while decoding it block by block, StartAt uses the index to jump to
+// the offset instead of decoding everything before it. It offers the same controls as RecordReader.
+type BinaryStreamReader struct {
+	reader      *BinaryReader
+	current     []byte
+	previous    time.Duration
+	hasPrevious bool
+	ignoreTime  bool
+	timing      Timing
+	markers     []Marker
+	markerIndex int
+	onMarker    func(Marker)
+	stopAt      time.Duration
+	hasStop     bool
+}
+
+// NewBinaryStreamReader reads the footer of the binary record in r and returns a reader of its events.
+func NewBinaryStreamReader(r io.ReadSeeker) (*BinaryStreamReader, error) {
+	reader, err := NewBinaryReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	markers := cloneMarkers(reader.Markers())
+	sort.SliceStable(markers, func(i, j int) bool {
+		return markers[i].Offset < markers[j].Offset
+	})
+
+	return &BinaryStreamReader{reader: reader, markers: markers}, nil
+}
+
+// Command returns the recorded command.
+func (sr *BinaryStreamReader) Command() string {
+	return sr.reader.Command()
+}
+
+// ExitCode returns the recorded exit code.
+func (sr *BinaryStreamReader) ExitCode() int {
+	return sr.reader.ExitCode()
+}
+
+// Markers returns the markers of the record.
+func (sr *BinaryStreamReader) Markers() []Marker {
+	return sr.markers
+}
+
+func (sr *BinaryStreamReader) IgnoreTime() {
+	sr.ignoreTime = true
+}
+
+func (sr *BinaryStreamReader) RespectTime() {
+	sr.ignoreTime = false
+}
+
+// SetTiming sets the policy applied to the delays between the events.
+func (sr *BinaryStreamReader) SetTiming(timing Timing) {
+	sr.timing = timing
+}
+
+// StartAt skips all events and markers before the given offset, it has to be called before reading.
+func (sr *BinaryStreamReader) StartAt(offset time.Duration) {
+	if offset < 0 {
+		offset = 0
+	}
+	sr.reader.Seek(int64(offset), io.SeekStart)
+	sr.markerIndex = sort.Search(len(sr.markers), func(i int) bool {
+		return sr.markers[i].Offset >= offset
+	})
+}
+
+// StopAt ends the reading at the first event at or after the given offset.
+func (sr *BinaryStreamReader) StopAt(offset time.Duration) {
+	sr.stopAt = offset
+	sr.hasStop = true
+}
+
+// OnMarker sets a function which is called with every marker once the reading passes it.
+// The function is called before the data following the marker is returned.
+func (sr *BinaryStreamReader) OnMarker(handler func(Marker)) {
+	sr.onMarker = handler
+}
+
+// Read reads the data of the events in the order of their offsets, waiting for the time between them.
+func (sr *BinaryStreamReader) Read(p []byte) (int, error) {
+	for len(sr.current) == 0 {
+		event, err := sr.reader.Next()
+		if err != nil {
+			return 0, err
+		}
+		if sr.hasStop && event.Offset >= sr.stopAt {
+			return 0, io.EOF
+		}
+
+		for sr.markerIndex < len(sr.markers) && sr.markers[sr.markerIndex].Offset <= event.Offset {
+			if sr.onMarker != nil {
+				sr.onMarker(sr.markers[sr.markerIndex])
+			}
+			sr.markerIndex++
+		}
+
+		if sr.hasPrevious && !sr.ignoreTime && event.Offset > sr.previous {
+			<-time.NewTimer(sr.timing.Delay(event.Offset - sr.previous)).C
+		}
+		sr.previous, sr.hasPrevious = event.Offset, true
+		sr.current = event.Data
+	}
+
+	n := copy(p, sr.current)
+	sr.current = sr.current[n:]
+	return n, nil
+}
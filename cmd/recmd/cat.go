@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/scaxyz/recmd"
+	"github.com/urfave/cli/v2"
+)
+
+func Cat(ctx *cli.Context) error {
+	record, err := loadRecord(ctx, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	streams, err := parseStreams(ctx.StringSlice("stream"))
+	if err != nil {
+		return err
+	}
+
+	mode, err := recmd.ParseANSIMode(ctx.String("ansi"))
+	if err != nil {
+		return err
+	}
+
+	output := bufio.NewWriter(os.Stdout)
+	defer output.Flush()
+
+	for _, line := range recmd.Lines(recmd.Events(record, streams...), mode) {
+		if ctx.Bool("timestamps") {
+			fmt.Fprintf(output, "[+%.3fs] ", line.Offset.Seconds())
+		}
+		fmt.Fprintln(output, line.Text)
+	}
+
+	return nil
+}
+
+// parseStreams parses stream names, an empty list selects all streams.
+func parseStreams(names []string) ([]recmd.Stream, error) {
+	var streams []recmd.Stream
+	for _, name := range names {
+		stream, err := recmd.ParseStream(name)
+		if err != nil {
+			return nil, err
+		}
+		streams = append(streams, stream)
+	}
+	return streams, nil
+}
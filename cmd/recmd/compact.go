@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/scaxyz/recmd"
+	"github.com/urfave/cli/v2"
+)
+
+func Compact(ctx *cli.Context) error {
+	recordFile := ctx.Args().First()
+
+	options := recmd.DefaultCompactOptions
+	var err error
+	if ctx.IsSet("merge") {
+		options.MergeWithin, err = parseOffset(ctx.String("merge"))
+		if err != nil {
+			return err
+		}
+	}
+	if ctx.IsSet("resolution") {
+		options.Resolution, err = parseOffset(ctx.String("resolution"))
+		if err != nil {
+			return err
+		}
+	}
+
+	record, err := loadRecord(ctx, recordFile)
+	if err != nil {
+		return err
+	}
+
+	compacted, err := recmd.Compact(record, options)
+	if err != nil {
+		return err
+	}
+
+	sizeBefore, err := encodedSize(record)
+	if err != nil {
+		return err
+	}
+	sizeAfter, err := encodedSize(compacted)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("chunks: %d -> %d\n", recmd.ChunkCount(record), recmd.ChunkCount(compacted))
+	fmt.Printf("size:   %d -> %d bytes (%.1f%% saved)\n", sizeBefore, sizeAfter, 100-float64(sizeAfter)*100/float64(sizeBefore))
+
+	return writeEdited(ctx, "compact", compacted, recordFile)
+}
+
+func encodedSize(record recmd.Record) (int, error) {
+	data, err := json.Marshal(record)
+	return len(data), err
+}
@@ -1,72 +1,87 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/scaxyz/recmd"
-	"github.com/tidwall/gjson"
 	"github.com/urfave/cli/v2"
 )
 
 func ConvertToStr(ctx *cli.Context) error {
 	recordFile := ctx.Args().First()
 
-	file, err := os.Open(recordFile)
+	record, err := loadRecord(ctx, recordFile)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	jsonData, err := io.ReadAll(file)
+	strRecord, err := record.ConvertTo(recmd.FormatString)
 	if err != nil {
 		return err
 	}
 
-	format := gjson.Get(string(jsonData), "format")
-	var record recmd.Record
-	switch format.String() {
-	case string(recmd.FormatBase64):
-		record = &recmd.ByteRecord{}
-	case string(recmd.FormatString):
-		record = &recmd.StringRecord{}
-	default:
-		return fmt.Errorf("unknown format: %s", format.String())
+	outputPath := ctx.Args().Get(1)
+
+	if strings.TrimSpace(outputPath) == "" {
+		outputPath = derivedPath(recordFile, string(strRecord.Format()))
 	}
 
-	err = json.Unmarshal(jsonData, &record)
+	key, err := outputKey(ctx, recordFile)
 	if err != nil {
 		return err
 	}
-	strRecord, err := record.ConvertTo(recmd.FormatString)
-	if err != nil {
-		return err
+
+	return writeRecord(outputPath, strRecord, key)
+}
+
+func Convert(ctx *cli.Context) error {
+	if ctx.NArg() < 1 || ctx.NArg() > 2 {
+		return fmt.Errorf("expected <input-file> [output-file]")
 	}
 
+	recordFile := ctx.Args().Get(0)
 	outputPath := ctx.Args().Get(1)
 
-	if strings.TrimSpace(outputPath) == "" {
-		ext := filepath.Ext(recordFile)
-		basenameAndPath := strings.TrimSuffix(recordFile, ext)
-		newFilePath := fmt.Sprint(basenameAndPath, "-", strRecord.Format(), ext)
-		outputPath = newFilePath
+	if outputPath == "" && !ctx.IsSet("to") && !ctx.IsSet("format") {
+		return fmt.Errorf("either an output file, --to or --format is required")
+	}
+
+	encoding := recmd.EncodingFromPath(outputPath)
+	if ctx.IsSet("to") {
+		var err error
+		encoding, err = recmd.ParseEncoding(ctx.String("to"))
+		if err != nil {
+			return err
+		}
 	}
 
-	outputFile, err := os.Create(outputPath)
+	record, err := loadRecord(ctx, recordFile)
 	if err != nil {
 		return err
 	}
-	defer outputFile.Close()
 
-	err = json.NewEncoder(outputFile).Encode(strRecord)
+	if ctx.IsSet("format") {
+		record, err = record.ConvertTo(recmd.RecordFormat(ctx.String("format")))
+		if err != nil {
+			return err
+		}
+	}
+
+	key, err := outputKey(ctx, recordFile)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(recordFile, recordExt(recordFile)) + encoding.Extension()
+		if key != nil {
+			outputPath += recmd.EncryptedExtension
+		}
+		if outputPath == recordFile {
+			outputPath = derivedPath(recordFile, string(record.Format()))
+		}
+	}
 
+	return writeRecordAs(outputPath, record, encoding, key)
 }
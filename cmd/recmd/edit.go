@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/scaxyz/recmd"
+	"github.com/urfave/cli/v2"
+)
+
+func Trim(ctx *cli.Context) error {
+	recordFile := ctx.Args().First()
+
+	from, to := time.Duration(0), time.Duration(-1)
+	var err error
+	if ctx.IsSet("from") {
+		from, err = parseOffset(ctx.String("from"))
+		if err != nil {
+			return err
+		}
+	}
+	if ctx.IsSet("to") {
+		to, err = parseOffset(ctx.String("to"))
+		if err != nil {
+			return err
+		}
+	}
+
+	record, err := loadRecord(ctx, recordFile)
+	if err != nil {
+		return err
+	}
+
+	trimmed, err := recmd.Trim(record, from, to)
+	if err != nil {
+		return err
+	}
+
+	return writeEdited(ctx, "trimmed", trimmed, recordFile)
+}
+
+func Cut(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return fmt.Errorf("expected <range> <file>")
+	}
+
+	recordFile := ctx.Args().Get(1)
+
+	record, err := loadRecord(ctx, recordFile)
+	if err != nil {
+		return err
+	}
+
+	from, to, err := parseRange(ctx.Args().First(), recmd.Duration(record))
+	if err != nil {
+		return err
+	}
+
+	cut, err := recmd.Cut(record, from, to)
+	if err != nil {
+		return err
+	}
+
+	return writeEdited(ctx, "cut", cut, recordFile)
+}
+
+func Concat(ctx *cli.Context) error {
+	if ctx.NArg() < 2 {
+		return fmt.Errorf("expected at least two files")
+	}
+
+	gap, err := parseOffset(ctx.String("gap"))
+	if err != nil {
+		return err
+	}
+
+	var records []recmd.Record
+	for _, recordFile := range ctx.Args().Slice() {
+		record, err := loadRecord(ctx, recordFile)
+		if err != nil {
+			return err
+		}
+		records = append(records, record)
+	}
+
+	joined, err := recmd.Concat(gap, records...)
+	if err != nil {
+		return err
+	}
+
+	return writeEdited(ctx, "concat", joined, ctx.Args().Slice()...)
+}
+
+func Splice(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return fmt.Errorf("expected <file> <insert-file>")
+	}
+
+	at, err := parseOffset(ctx.String("at"))
+	if err != nil {
+		return err
+	}
+
+	gap, err := parseOffset(ctx.String("gap"))
+	if err != nil {
+		return err
+	}
+
+	record, err := loadRecord(ctx, ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	insert, err := loadRecord(ctx, ctx.Args().Get(1))
+	if err != nil {
+		return err
+	}
+
+	spliced, err := recmd.Splice(record, at, insert, gap)
+	if err != nil {
+		return err
+	}
+
+	return writeEdited(ctx, "spliced", spliced, ctx.Args().Get(0), ctx.Args().Get(1))
+}
+
+// parseRange parses a range like "10s-40s", a missing start or end means the start or end of the record.
+func parseRange(value string, end time.Duration) (time.Duration, time.Duration, error) {
+	fromValue, toValue, found := strings.Cut(value, "-")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid range: %q, expected <from>-<to>", value)
+	}
+
+	from, to := time.Duration(0), end+1
+	var err error
+	if strings.TrimSpace(fromValue) != "" {
+		from, err = parseOffset(fromValue)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	if strings.TrimSpace(toValue) != "" {
+		to, err = parseOffset(toValue)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return from, to, nil
+}
+
+// writeEdited writes the record edited from the record files to --output or a path derived from the first record file.
+// The record is encrypted if any of the record files is.
+func writeEdited(ctx *cli.Context, suffix string, record recmd.Record, recordFiles ...string) error {
+	outputPath := ctx.Path("output")
+	if strings.TrimSpace(outputPath) == "" {
+		outputPath = derivedPath(recordFiles[0], suffix)
+	}
+	key, err := outputKey(ctx, recordFiles...)
+	if err != nil {
+		return err
+	}
+	return writeRecord(outputPath, record, key)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/scaxyz/recmd"
+	"github.com/urfave/cli/v2"
+)
+
+func Encrypt(ctx *cli.Context) error {
+	recordFile := ctx.Args().First()
+
+	key, err := keyFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return fmt.Errorf("a passphrase or key file is required, see --passphrase and --key-file")
+	}
+
+	encrypted, err := isEncryptedFile(recordFile)
+	if err != nil {
+		return err
+	}
+	if encrypted {
+		return fmt.Errorf("%s is already encrypted", recordFile)
+	}
+
+	outputPath := ctx.Path("output")
+	if strings.TrimSpace(outputPath) == "" {
+		outputPath = recordFile + recmd.EncryptedExtension
+	}
+
+	return transformFile(recordFile, outputPath, func(w io.Writer, r io.Reader) error {
+		writer, err := recmd.NewEncryptWriter(w, key)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(writer, r)
+		if err != nil {
+			return err
+		}
+		return writer.Close()
+	})
+}
+
+func Decrypt(ctx *cli.Context) error {
+	recordFile := ctx.Args().First()
+
+	key, err := keyFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return fmt.Errorf("a passphrase or key file is required, see --passphrase and --key-file")
+	}
+
+	encrypted, err := isEncryptedFile(recordFile)
+	if err != nil {
+		return err
+	}
+	if !encrypted {
+		return fmt.Errorf("%s is not encrypted", recordFile)
+	}
+
+	outputPath := ctx.Path("output")
+	if strings.TrimSpace(outputPath) == "" {
+		outputPath = recmd.TrimEncryptedExtension(recordFile)
+		if outputPath == recordFile {
+			outputPath = derivedPath(recordFile, "decrypted")
+		}
+	}
+
+	return transformFile(recordFile, outputPath, func(w io.Writer, r io.Reader) error {
+		reader, err := recmd.NewDecryptReader(r, key)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, reader)
+		return err
+	})
+}
+
+// transformFile writes the input file transformed by transform to the output file.
+// The output file is removed if the transformation fails, so no partial output is left behind.
+func transformFile(inputPath string, outputPath string, transform func(w io.Writer, r io.Reader) error) error {
+	if inputPath == outputPath {
+		return fmt.Errorf("input and output file are the same: %s", inputPath)
+	}
+
+	input, err := os.Open(inputPath)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	output, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+
+	err = transform(output, input)
+	if err == nil {
+		err = output.Close()
+	} else {
+		output.Close()
+	}
+	if err != nil {
+		os.Remove(outputPath)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/scaxyz/recmd"
+	"github.com/urfave/cli/v2"
+)
+
+// writeRecord saves the record to path, replacing any existing file.
+// The encoding and compression are chosen by the extension of path, a non nil key encrypts the file.
+func writeRecord(path string, record recmd.Record, key *recmd.Key) error {
+	return writeRecordAs(path, record, recmd.EncodingFromPath(path), key)
+}
+
+// writeRecordAs saves the record to path in the given encoding, compressed according to the extension of path.
+// A non nil key encrypts the file. The record is written to a temporary file next to path which replaces path
+// once it is complete, so a failing encoding leaves an existing file untouched.
+func writeRecordAs(path string, record recmd.Record, encoding recmd.Encoding, key *recmd.Key) error {
+	mode := os.FileMode(0644)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		// replacing a symlink would turn it into a copy
+		path = resolved
+		if info, err := os.Stat(path); err == nil {
+			mode = info.Mode().Perm()
+		}
+	}
+
+	outputFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(outputFile.Name())
+	defer outputFile.Close()
+
+	writer, err := newRecordWriter(outputFile, path, key)
+	if err != nil {
+		return err
+	}
+
+	err = recmd.Encode(writer, record, encoding)
+	if err != nil {
+		return err
+	}
+
+	if encoding == recmd.EncodingTTYRec {
+		if losses := recmd.TTYRecLosses(record); len(losses) > 0 {
+			log.Printf("warning: %s files don't keep %s of the record", encoding, strings.Join(losses, ", "))
+		}
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	err = outputFile.Chmod(mode)
+	if err != nil {
+		return err
+	}
+
+	err = outputFile.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(outputFile.Name(), path)
+}
+
+// newRecordWriter returns a writer compressing according to the extension of path and encrypting with a non nil key.
+// Closing it flushes all data but doesn't close w.
+func newRecordWriter(w io.Writer, path string, key *recmd.Key) (io.WriteCloser, error) {
+	if key == nil {
+		return recmd.NewCompressWriter(w, recmd.CompressionFromPath(path))
+	}
+
+	encrypter, err := recmd.NewEncryptWriter(w, key)
+	if err != nil {
+		return nil, err
+	}
+
+	compressor, err := recmd.NewCompressWriter(encrypter, recmd.CompressionFromPath(path))
+	if err != nil {
+		return nil, err
+	}
+
+	return chainedWriteCloser{WriteCloser: compressor, next: encrypter}, nil
+}
+
+// chainedWriteCloser closes next after the writer itself.
+type chainedWriteCloser struct {
+	io.WriteCloser
+	next io.Closer
+}
+
+func (c chainedWriteCloser) Close() error {
+	err := c.WriteCloser.Close()
+	if err != nil {
+		return err
+	}
+	return c.next.Close()
+}
+
+// keyFromContext returns the key given by --passphrase or --key-file, nil if neither is set.
+func keyFromContext(ctx *cli.Context) (*recmd.Key, error) {
+	passphrase, keyFile := ctx.String("passphrase"), ctx.Path("key-file")
+	switch {
+	case passphrase != "" && keyFile != "":
+		return nil, fmt.Errorf("either a passphrase or a key file can be used, not both")
+	case passphrase != "":
+		return recmd.PassphraseKey(passphrase), nil
+	case keyFile != "":
+		return recmd.ReadKeyFile(keyFile)
+	default:
+		return nil, nil
+	}
+}
+
+// loadRecord loads the record stored at path, decrypting it with the key from the context.
+func loadRecord(ctx *cli.Context, path string) (recmd.Record, error) {
+	key, err := keyFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return recmd.LoadFile(path, recmd.WithKey(key))
+}
+
+// openRecordFile opens the record stored at path and returns a reader of its decrypted and decompressed data.
+// The returned file has to be closed after use.
+func openRecordFile(ctx *cli.Context, path string) (*os.File, io.Reader, error) {
+	key, err := keyFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opened, err := recmd.Open(file, recmd.WithKey(key))
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	return file, opened, nil
+}
+
+// outputKey returns the key to write records derived from the records at paths with,
+// nil unless one of them is encrypted so edits of encrypted records stay encrypted.
+func outputKey(ctx *cli.Context, paths ...string) (*recmd.Key, error) {
+	for _, path := range paths {
+		encrypted, err := isEncryptedFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if encrypted {
+			return keyFromContext(ctx)
+		}
+	}
+	return nil, nil
+}
+
+// isEncryptedFile reports whether the record stored at path is encrypted.
+func isEncryptedFile(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	return recmd.IsEncrypted(bufio.NewReader(file))
+}
+
+// derivedPath returns the path with the suffix added to the file name: rec.json -> rec-<suffix>.json
+func derivedPath(path string, suffix string) string {
+	ext := recordExt(path)
+	basenameAndPath := strings.TrimSuffix(path, ext)
+	return fmt.Sprint(basenameAndPath, "-", suffix, ext)
+}
+
+// recordExt returns the extension of path, including the compression and encryption extensions: rec.json.gz.enc -> .json.gz.enc
+func recordExt(path string) string {
+	trimmed := recmd.TrimEncryptedExtension(path)
+	ext := filepath.Ext(trimmed)
+	if recmd.CompressionFromPath(trimmed) != recmd.CompressionNone {
+		ext = filepath.Ext(strings.TrimSuffix(trimmed, ext)) + ext
+	}
+	return ext + strings.TrimPrefix(path, trimmed)
+}
+
+// isRecordFile reports whether the path looks like a record file, used when searching directories.
+func isRecordFile(path string) bool {
+	path = strings.TrimSuffix(recmd.TrimEncryptedExtension(path), recmd.CompressionFromPath(path).Extension())
+	if strings.HasSuffix(path, ".yml") {
+		return true
+	}
+	for _, encoding := range recmd.Encodings {
+		if strings.HasSuffix(path, encoding.Extension()) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectFileEncoding detects the encoding of the record stored at path.
+func detectFileEncoding(ctx *cli.Context, path string) (recmd.Encoding, error) {
+	file, opened, err := openRecordFile(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return recmd.DetectEncoding(bufio.NewReader(opened))
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/scaxyz/recmd/screen"
+	"github.com/urfave/cli/v2"
+)
+
+func ExportGIF(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("expected <file>")
+	}
+	recordFile := ctx.Args().First()
+
+	theme, err := screen.ParseTheme(ctx.String("theme"))
+	if err != nil {
+		return err
+	}
+
+	timing, err := timingFromFlags(ctx)
+	if err != nil {
+		return err
+	}
+
+	record, err := loadRecord(ctx, recordFile)
+	if err != nil {
+		return err
+	}
+
+	columns, rows := screenSize(ctx, record)
+	frames := screen.Frames(record, columns, rows, timing)
+
+	outputPath := ctx.Path("output")
+	if strings.TrimSpace(outputPath) == "" {
+		outputPath = strings.TrimSuffix(recordFile, recordExt(recordFile)) + ".gif"
+	}
+
+	output, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	err = screen.EncodeGIF(output, frames, screen.GIFOptions{
+		Theme: theme,
+		Scale: ctx.Int("scale"),
+		FPS:   ctx.Float64("fps"),
+		Loop:  ctx.Bool("loop"),
+		Hold:  ctx.Duration("hold"),
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Println("wrote gif to " + outputPath)
+	return output.Close()
+}
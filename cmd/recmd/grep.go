@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/scaxyz/recmd"
+	"github.com/urfave/cli/v2"
+)
+
+type grepHit struct {
+	File string `json:"file"`
+	recmd.Match
+}
+
+func Grep(ctx *cli.Context) error {
+	if ctx.NArg() < 2 {
+		return fmt.Errorf("expected <pattern> <files|dirs>...")
+	}
+
+	expression := ctx.Args().First()
+	if ctx.Bool("fixed-strings") {
+		expression = regexp.QuoteMeta(expression)
+	}
+	if ctx.Bool("ignore-case") {
+		expression = "(?i)" + expression
+	}
+	pattern, err := regexp.Compile(expression)
+	if err != nil {
+		return err
+	}
+
+	streams, err := parseStreams(ctx.StringSlice("stream"))
+	if err != nil {
+		return err
+	}
+
+	files, err := collectRecordFiles(ctx.Args().Slice()[1:])
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	found := false
+
+	for _, file := range files {
+		record, err := loadRecord(ctx, file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+			continue
+		}
+
+		for _, match := range recmd.Search(record, pattern, streams...) {
+			found = true
+
+			if ctx.Bool("json") {
+				err = encoder.Encode(grepHit{File: file, Match: match})
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			fmt.Printf("%s:%s:+%.3fs: %s\n", file, match.Stream, match.Offset.Seconds(), match.Line)
+		}
+	}
+
+	if !found {
+		return cli.Exit("", 1)
+	}
+
+	return nil
+}
+
+// collectRecordFiles expands directories to the record files inside them, files are kept as they are.
+func collectRecordFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		stat, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !stat.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() && isRecordFile(path) {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
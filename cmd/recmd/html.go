@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/scaxyz/recmd"
+	"github.com/urfave/cli/v2"
+)
+
+func ExportHTML(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("expected <file>")
+	}
+	recordFile := ctx.Args().First()
+
+	record, err := loadRecord(ctx, recordFile)
+	if err != nil {
+		return err
+	}
+
+	outputPath := ctx.Path("output")
+	if strings.TrimSpace(outputPath) == "" {
+		outputPath = strings.TrimSuffix(recordFile, recordExt(recordFile)) + ".html"
+	}
+
+	output, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	err = recmd.EncodeHTML(output, record, ctx.String("title"))
+	if err != nil {
+		return err
+	}
+
+	log.Println("wrote player to " + outputPath)
+	return output.Close()
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/scaxyz/recmd"
+	"github.com/urfave/cli/v2"
+)
+
+var sparklineLevels = []rune("▁▂▃▄▅▆▇█")
+
+type infoOutput struct {
+	File     string             `json:"file"`
+	Command  string             `json:"command"`
+	Format   recmd.RecordFormat `json:"format"`
+	ExitCode int                `json:"exitcode"`
+	recmd.Stats
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Markers  []recmd.Marker    `json:"markers,omitempty"`
+}
+
+func Info(ctx *cli.Context) error {
+	recordFile := ctx.Args().First()
+
+	record, err := loadRecord(ctx, recordFile)
+	if err != nil {
+		return err
+	}
+
+	info := infoOutput{
+		File:     recordFile,
+		Command:  record.Command(),
+		Format:   record.Format(),
+		ExitCode: record.ExitCode(),
+		Stats:    recmd.Statistics(record, ctx.Int("buckets")),
+		Metadata: record.Metadata(),
+		Markers:  record.Markers(),
+	}
+
+	if ctx.Bool("json") {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	}
+
+	printInfo(info)
+	return nil
+}
+
+func printInfo(info infoOutput) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(writer, "File:\t%s\n", info.File)
+	fmt.Fprintf(writer, "Command:\t%s\n", info.Command)
+	fmt.Fprintf(writer, "Format:\t%s\n", info.Format)
+	fmt.Fprintf(writer, "Exit code:\t%d\n", info.ExitCode)
+	fmt.Fprintf(writer, "Duration:\t%s\n", info.Duration)
+	if info.FirstOutput >= 0 {
+		fmt.Fprintf(writer, "First output:\t%s\n", info.FirstOutput)
+	} else {
+		fmt.Fprintf(writer, "First output:\t-\n")
+	}
+	if len(info.Throughput) > 0 {
+		fmt.Fprintf(writer, "Throughput:\t%s\n", sparkline(info.Throughput))
+	}
+	writer.Flush()
+
+	fmt.Println()
+	writer = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "Stream\tChunks\tBytes\tLargest gap")
+	// extra streams like fd3 of strace imports follow out, in and err
+	streams := append([]recmd.Stream{}, recmd.Streams...)
+	var extra []recmd.Stream
+	for stream := range info.Streams {
+		if stream != recmd.StreamOut && stream != recmd.StreamIn && stream != recmd.StreamErr {
+			extra = append(extra, stream)
+		}
+	}
+	sort.Slice(extra, func(i, j int) bool {
+		return extra[i] < extra[j]
+	})
+	for _, stream := range append(streams, extra...) {
+		stats := info.Streams[stream]
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%s\n", stream, stats.Chunks, stats.Bytes, stats.LargestGap)
+	}
+	writer.Flush()
+
+	if len(info.Metadata) > 0 {
+		fmt.Println()
+		fmt.Println("Metadata:")
+		keys := make([]string, 0, len(info.Metadata))
+		for key := range info.Metadata {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		writer = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, key := range keys {
+			fmt.Fprintf(writer, "  %s\t%s\n", key, info.Metadata[key])
+		}
+		writer.Flush()
+	}
+
+	if len(info.Markers) > 0 {
+		fmt.Println()
+		fmt.Println("Markers:")
+		writer = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, marker := range info.Markers {
+			fmt.Fprintf(writer, "  %s\t%s\n", marker.Offset.Round(time.Millisecond), marker.Label)
+		}
+		writer.Flush()
+	}
+}
+
+// sparkline renders the values as a line of block characters scaled to the largest value.
+func sparkline(values []int) string {
+	max := 0
+	for _, value := range values {
+		if value > max {
+			max = value
+		}
+	}
+
+	builder := strings.Builder{}
+	for _, value := range values {
+		if max == 0 || value == 0 {
+			builder.WriteRune(' ')
+			continue
+		}
+		level := (value*len(sparklineLevels) - 1) / max
+		builder.WriteRune(sparklineLevels[level])
+	}
+
+	return builder.String()
+}
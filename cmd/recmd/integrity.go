@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/scaxyz/recmd"
+	"github.com/urfave/cli/v2"
+)
+
+func VerifyIntegrity(ctx *cli.Context) error {
+	if ctx.NArg() == 0 {
+		return fmt.Errorf("no files specified")
+	}
+
+	failed := false
+	for _, recordFile := range ctx.Args().Slice() {
+		record, err := loadRecord(ctx, recordFile)
+		if err != nil {
+			fmt.Printf("%s: error: %s\n", recordFile, err)
+			failed = true
+			continue
+		}
+
+		checks, err := recmd.VerifyDigests(record)
+		if err != nil {
+			fmt.Printf("%s: error: %s\n", recordFile, err)
+			failed = true
+			continue
+		}
+
+		ok := true
+		for _, check := range checks {
+			switch {
+			case check.OK():
+				continue
+			case check.Stored == "":
+				fmt.Printf("%s: %s: missing\n", recordFile, check.Key)
+			default:
+				fmt.Printf("%s: %s: mismatch, stored %s, computed %s\n", recordFile, check.Key, check.Stored, check.Computed)
+			}
+			ok = false
+		}
+
+		if ok {
+			fmt.Printf("%s: ok\n", recordFile)
+		} else {
+			failed = true
+		}
+	}
+
+	if failed {
+		return cli.Exit("", 1)
+	}
+
+	return nil
+}
+
+func Sign(ctx *cli.Context) error {
+	recordFile := ctx.Args().First()
+	if recordFile == "" {
+		return fmt.Errorf("no file specified")
+	}
+
+	keyData, err := os.ReadFile(ctx.Path("key"))
+	if err != nil {
+		return err
+	}
+	key, err := recmd.ParsePrivateKey(keyData)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(recordFile)
+	if err != nil {
+		return err
+	}
+
+	signatureFile := ctx.Path("signature")
+	if strings.TrimSpace(signatureFile) == "" {
+		signatureFile = recordFile + recmd.SignatureExtension
+	}
+
+	err = os.WriteFile(signatureFile, recmd.Sign(data, key), 0644)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("wrote signature to %s\n", signatureFile)
+	return nil
+}
+
+func VerifySig(ctx *cli.Context) error {
+	recordFile := ctx.Args().First()
+	if recordFile == "" {
+		return fmt.Errorf("no file specified")
+	}
+
+	keyData, err := os.ReadFile(ctx.Path("key"))
+	if err != nil {
+		return err
+	}
+	key, err := recmd.ParsePublicKey(keyData)
+	if err != nil {
+		return err
+	}
+
+	signatureFile := ctx.Path("signature")
+	if strings.TrimSpace(signatureFile) == "" {
+		signatureFile = recordFile + recmd.SignatureExtension
+	}
+
+	signature, err := os.ReadFile(signatureFile)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(recordFile)
+	if err != nil {
+		return err
+	}
+
+	err = recmd.VerifySignature(data, signature, key)
+	if err != nil {
+		fmt.Printf("%s: %s\n", recordFile, err)
+		return cli.Exit("", 1)
+	}
+
+	fmt.Printf("%s: signature ok\n", recordFile)
+	return nil
+}
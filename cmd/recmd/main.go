@@ -3,8 +3,11 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/scaxyz/recmd"
+	"github.com/scaxyz/recmd/screen"
 	"github.com/urfave/cli/v2"
 )
 
@@ -21,6 +24,19 @@ func main() {
 	app.Version = version
 	app.Usage = "record or replay inputs and outputs of a command"
 
+	app.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:    "passphrase",
+			Usage:   "Passphrase to encrypt and decrypt records with",
+			EnvVars: []string{"RECMD_PASSPHRASE"},
+		},
+		&cli.PathFlag{
+			Name:    "key-file",
+			Usage:   "Key file to encrypt and decrypt records with, its content should be random like 'head -c 32 /dev/urandom'",
+			EnvVars: []string{"RECMD_KEY_FILE"},
+		},
+	}
+
 	app.Commands = []*cli.Command{
 		{
 			Name:    "record",
@@ -41,7 +57,7 @@ func main() {
 				&cli.BoolFlag{
 					Name:    "save-with-plain-text",
 					Aliases: []string{"plain-text", "plain", "pt", "p"},
-					Usage:   "Saves to json with 'in','out' and 'err' as plain texts instead of base64 encodings",
+					Usage:   "Saves to json with 'in','out' and 'err' as plain texts instead of base64 encodings, chunks which are not valid UTF-8 stay base64",
 				},
 				&cli.StringFlag{
 					Name:  "time-format",
@@ -53,6 +69,38 @@ func main() {
 					Aliases: []string{"inter", "stdin"},
 					Usage:   "Use standard input",
 				},
+				&cli.StringFlag{
+					Name:  "marker-key",
+					Usage: "Places a marker when the key is read from the input, a single character or a control key like '^]'",
+				},
+				&cli.BoolFlag{
+					Name:  "compact",
+					Usage: "Merge chunks closer than 10ms and round offsets to milliseconds",
+				},
+				&cli.StringFlag{
+					Name:  "compress",
+					Usage: "Compress the output file with gzip or zstd, also chosen by an output file ending in .gz or .zst",
+				},
+				&cli.StringSliceFlag{
+					Name:  "meta",
+					Usage: "Stores a key=value pair in the metadata of the record, can be repeated",
+				},
+				&cli.BoolFlag{
+					Name:  "encrypt",
+					Usage: "Encrypts the output file with the passphrase or key file and adds '.enc' to its name",
+				},
+				&cli.BoolFlag{
+					Name:  "redact",
+					Usage: "Masks common token shapes like AWS keys, GitHub tokens, JWTs and private keys in the record",
+				},
+				&cli.StringSliceFlag{
+					Name:  "redact-rule",
+					Usage: "Masks matches of a name=regex rule in the record, only the first group if the regex has groups, can be repeated",
+				},
+				&cli.StringSliceFlag{
+					Name:  "redact-env",
+					Usage: "Masks the value of the environment variable in the record, can be repeated",
+				},
 			},
 			Action: Record,
 		},
@@ -71,6 +119,35 @@ func main() {
 					Usage:   "Ignore delays while replaying",
 					Aliases: []string{"quick"},
 				},
+				&cli.Float64Flag{
+					Name:  "speed",
+					Usage: "Divides all delays, 2 replays twice as fast",
+					Value: 1,
+				},
+				&cli.DurationFlag{
+					Name:  "idle-limit",
+					Usage: "Shortens delays longer than the limit to the limit, like 2s",
+				},
+				&cli.StringFlag{
+					Name:    "from-marker",
+					Usage:   "Start replaying at the marker with the given label",
+					Aliases: []string{"from"},
+				},
+				&cli.StringFlag{
+					Name:    "to-marker",
+					Usage:   "Stop replaying at the marker with the given label",
+					Aliases: []string{"to"},
+				},
+				&cli.BoolFlag{
+					Name:    "pause-at-markers",
+					Usage:   "Wait for enter at every marker",
+					Aliases: []string{"pause"},
+				},
+				&cli.BoolFlag{
+					Name:    "follow",
+					Usage:   "Keep waiting for new events of an ndjson record which is still being recorded",
+					Aliases: []string{"f"},
+				},
 			},
 			Action: Replay,
 		},
@@ -81,6 +158,637 @@ func main() {
 			Action:    ConvertToStr,
 			UsageText: "recmd convert-to-plain-text <input-file> [output-file]",
 		},
+		{
+			Name:      "convert",
+			Aliases:   []string{"conv"},
+			Usage:     "Converts a record to another encoding (json, ndjson, binary, yaml, ttyrec) or format (base64, string, auto)",
+			UsageText: "recmd convert [command options] <input-file> [output-file]",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "to",
+					Usage: "Encoding of the output: json, ndjson, binary, yaml or ttyrec (default: chosen by the output extension .json, .ndjson, .recmd, .yaml or .ttyrec)",
+				},
+				&cli.StringFlag{
+					Name:  "format",
+					Usage: "Format of the chunks for json output: base64, string or auto (plain text if valid UTF-8, base64 otherwise)",
+				},
+			},
+			Action: Convert,
+		},
+		{
+			Name:      "info",
+			Usage:     "Shows statistics and a summary of a record",
+			UsageText: "recmd info [command options] <file>",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "json",
+					Usage: "Print the summary as json",
+				},
+				&cli.IntFlag{
+					Name:  "buckets",
+					Usage: "Number of time buckets of the throughput sparkline",
+					Value: 40,
+				},
+			},
+			Action: Info,
+		},
+		{
+			Name:      "cat",
+			Usage:     "Prints the content of a record without delays",
+			UsageText: "recmd cat [command options] <file>",
+			Flags: []cli.Flag{
+				&cli.StringSliceFlag{
+					Name:    "stream",
+					Aliases: []string{"s"},
+					Usage:   "Only print the given streams (out, in, err or extra streams like fd3), can be repeated",
+				},
+				&cli.BoolFlag{
+					Name:    "timestamps",
+					Aliases: []string{"t"},
+					Usage:   "Prefix every line with its offset like [+1.234s]",
+				},
+				&cli.StringFlag{
+					Name:  "ansi",
+					Usage: "How to handle escape sequences: strip, keep or render (applies carriage returns and backspaces)",
+					Value: string(recmd.ANSIRender),
+				},
+			},
+			Action: Cat,
+		},
+		{
+			Name:      "grep",
+			Usage:     "Searches the content of records",
+			UsageText: "recmd grep [command options] <pattern> <files|dirs>...",
+			Flags: []cli.Flag{
+				&cli.StringSliceFlag{
+					Name:    "stream",
+					Aliases: []string{"s"},
+					Usage:   "Only search the given streams (out, in, err or extra streams like fd3), can be repeated",
+				},
+				&cli.BoolFlag{
+					Name:    "ignore-case",
+					Aliases: []string{"i"},
+					Usage:   "Ignore case distinctions",
+				},
+				&cli.BoolFlag{
+					Name:    "fixed-strings",
+					Aliases: []string{"F"},
+					Usage:   "Treat the pattern as a literal string instead of a regular expression",
+				},
+				&cli.BoolFlag{
+					Name:  "json",
+					Usage: "Print every match as a json line",
+				},
+			},
+			Action: Grep,
+		},
+		{
+			Name:      "trim",
+			Usage:     "Keeps only the part of a record between --from and --to",
+			UsageText: "recmd trim [command options] <file>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "from",
+					Usage: "Start of the kept part, like '1m2.5s' or '42' (seconds)",
+				},
+				&cli.StringFlag{
+					Name:  "to",
+					Usage: "End of the kept part, like '1m2.5s' or '42' (seconds)",
+				},
+				&cli.PathFlag{
+					Name:    "output",
+					Usage:   "Output file (default: <input-name>-trimmed.<input-ext>)",
+					Aliases: []string{"o"},
+				},
+			},
+			Action: Trim,
+		},
+		{
+			Name:      "cut",
+			Usage:     "Removes a range like '10s-40s' from a record, a missing start or end means the start or end of the record",
+			UsageText: "recmd cut [command options] <range> <file>",
+			Flags: []cli.Flag{
+				&cli.PathFlag{
+					Name:    "output",
+					Usage:   "Output file (default: <input-name>-cut.<input-ext>)",
+					Aliases: []string{"o"},
+				},
+			},
+			Action: Cut,
+		},
+		{
+			Name:      "concat",
+			Usage:     "Joins records one after another",
+			UsageText: "recmd concat [command options] <file> <file>...",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "gap",
+					Usage: "Pause between the records",
+					Value: "0s",
+				},
+				&cli.PathFlag{
+					Name:    "output",
+					Usage:   "Output file (default: <input-name>-concat.<input-ext>)",
+					Aliases: []string{"o"},
+				},
+			},
+			Action: Concat,
+		},
+		{
+			Name:      "splice",
+			Usage:     "Inserts a record into another one, moving the rest of it back",
+			UsageText: "recmd splice [command options] <file> <insert-file>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     "at",
+					Usage:    "Offset to insert the record at",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:  "gap",
+					Usage: "Pause after the inserted record",
+					Value: "0s",
+				},
+				&cli.PathFlag{
+					Name:    "output",
+					Usage:   "Output file (default: <input-name>-spliced.<input-ext>)",
+					Aliases: []string{"o"},
+				},
+			},
+			Action: Splice,
+		},
+		{
+			Name:      "compact",
+			Usage:     "Merges close chunks and rounds offsets to shrink a record",
+			UsageText: "recmd compact [command options] <file>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "merge",
+					Usage: "Merge chunks of a stream which follow each other, as long as the merged chunk spans less than this",
+					Value: recmd.DefaultCompactOptions.MergeWithin.String(),
+				},
+				&cli.StringFlag{
+					Name:  "resolution",
+					Usage: "Round offsets down to a multiple of this",
+					Value: recmd.DefaultCompactOptions.Resolution.String(),
+				},
+				&cli.PathFlag{
+					Name:    "output",
+					Usage:   "Output file (default: <input-name>-compact.<input-ext>)",
+					Aliases: []string{"o"},
+				},
+			},
+			Action: Compact,
+		},
+		{
+			Name:      "validate",
+			Usage:     "Checks records for problems like invalid chunks, offsets or missing fields",
+			UsageText: "recmd validate <files...>",
+			Action:    Validate,
+		},
+		{
+			Name:      "encrypt",
+			Usage:     "Encrypts a record file with the passphrase or key file",
+			UsageText: "recmd --passphrase <passphrase> encrypt [-o <output>] <file>",
+			Flags: []cli.Flag{
+				&cli.PathFlag{
+					Name:    "output",
+					Aliases: []string{"o"},
+					Usage:   "Output file, defaults to the input file with '.enc' added",
+				},
+			},
+			Action: Encrypt,
+		},
+		{
+			Name:      "decrypt",
+			Usage:     "Decrypts an encrypted record file",
+			UsageText: "recmd --passphrase <passphrase> decrypt [-o <output>] <file>",
+			Flags: []cli.Flag{
+				&cli.PathFlag{
+					Name:    "output",
+					Aliases: []string{"o"},
+					Usage:   "Output file, defaults to the input file without '.enc'",
+				},
+			},
+			Action: Decrypt,
+		},
+		{
+			Name:      "redact",
+			Usage:     "Masks secrets in a record, keeping the timing of all chunks",
+			UsageText: "recmd redact [--rule <name>=<regex>] [--env <name>] [-i] <file>",
+			Flags: []cli.Flag{
+				&cli.StringSliceFlag{
+					Name:  "rule",
+					Usage: "Masks matches of a name=regex rule, only the first group if the regex has groups, can be repeated",
+				},
+				&cli.StringSliceFlag{
+					Name:  "env",
+					Usage: "Masks the value of the environment variable, can be repeated",
+				},
+				&cli.BoolFlag{
+					Name:  "no-token-rules",
+					Usage: "Don't mask common token shapes like AWS keys, GitHub tokens, JWTs and private keys",
+				},
+				&cli.StringFlag{
+					Name:  "mask",
+					Usage: "Replacement for secrets",
+					Value: recmd.DefaultRedactMask,
+				},
+				&cli.BoolFlag{
+					Name:    "interactive",
+					Aliases: []string{"i"},
+					Usage:   "Asks for every secret whether it should be masked",
+				},
+				&cli.PathFlag{
+					Name:    "output",
+					Aliases: []string{"o"},
+					Usage:   "Output file (default: <input-name>-redacted.<input-ext>)",
+				},
+			},
+			Action: Redact,
+		},
+		{
+			Name:      "verify-integrity",
+			Usage:     "Recomputes the digests stored while recording and reports records which were altered",
+			UsageText: "recmd verify-integrity <files...>",
+			Action:    VerifyIntegrity,
+		},
+		{
+			Name:      "sign",
+			Usage:     "Creates a detached Ed25519 signature of a record file",
+			UsageText: "recmd sign --key <private-key> [--signature <file>] <file>",
+			Flags: []cli.Flag{
+				&cli.PathFlag{
+					Name:     "key",
+					Aliases:  []string{"k"},
+					Usage:    "Ed25519 private key in PEM (openssl genpkey -algorithm ed25519) or OpenSSH format (ssh-keygen -t ed25519)",
+					Required: true,
+				},
+				&cli.PathFlag{
+					Name:    "signature",
+					Aliases: []string{"s"},
+					Usage:   "Signature file, defaults to the record file with '.sig' added",
+				},
+			},
+			Action: Sign,
+		},
+		{
+			Name:      "verify-sig",
+			Usage:     "Verifies the detached signature of a record file",
+			UsageText: "recmd verify-sig --key <public-key> [--signature <file>] <file>",
+			Flags: []cli.Flag{
+				&cli.PathFlag{
+					Name:     "key",
+					Aliases:  []string{"k"},
+					Usage:    "Ed25519 public key in PEM or OpenSSH format",
+					Required: true,
+				},
+				&cli.PathFlag{
+					Name:    "signature",
+					Aliases: []string{"s"},
+					Usage:   "Signature file, defaults to the record file with '.sig' added",
+				},
+			},
+			Action: VerifySig,
+		},
+		{
+			Name:  "mark",
+			Usage: "Manage the markers of a record",
+			Subcommands: []*cli.Command{
+				{
+					Name:      "add",
+					Usage:     "Adds a marker at an offset like '1m2.5s' or '42' (seconds)",
+					UsageText: "recmd mark add <file> <offset> <label>",
+					Action:    MarkAdd,
+				},
+				{
+					Name:      "list",
+					Aliases:   []string{"ls"},
+					Usage:     "Lists the markers of a record",
+					UsageText: "recmd mark list <file>",
+					Action:    MarkList,
+				},
+			},
+		},
+		{
+			Name:      "screenshot",
+			Usage:     "Prints the screen a terminal shows after the output of a record up to an offset",
+			UsageText: "recmd screenshot [command options] <file>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "at",
+					Usage: "Offset like '1m2.5s' or '42' (seconds), or 'exit' for the screen after all output",
+					Value: "exit",
+				},
+				&cli.StringFlag{
+					Name:  "format",
+					Usage: "Output format: text, ansi (text with colors and attributes) or html",
+					Value: "text",
+				},
+				&cli.StringFlag{
+					Name:  "theme",
+					Usage: "Colors of the html output: " + strings.Join(screen.ThemeNames(), ", "),
+					Value: screen.DefaultTheme,
+				},
+				&cli.IntFlag{
+					Name:  "columns",
+					Usage: "Width of the screen, 0 uses the recorded size or 80",
+				},
+				&cli.IntFlag{
+					Name:  "rows",
+					Usage: "Height of the screen, 0 uses the recorded size or 24",
+				},
+				&cli.PathFlag{
+					Name:    "output",
+					Aliases: []string{"o"},
+					Usage:   "Output file (default: stdout)",
+				},
+			},
+			Action: Screenshot,
+		},
+		{
+			Name:  "import",
+			Usage: "Creates records from the recordings of other tools",
+			Subcommands: []*cli.Command{
+				{
+					Name:      "script",
+					Usage:     "Imports the timing and log files of util-linux script (script -T <timing> -B <log>), classic and advanced timing formats",
+					UsageText: "recmd import script --timing <file> --log <file> [--log-in <file>] [-o <output>]",
+					Flags: []cli.Flag{
+						&cli.PathFlag{
+							Name:     "timing",
+							Aliases:  []string{"t"},
+							Usage:    "Timing file",
+							Required: true,
+						},
+						&cli.PathFlag{
+							Name:     "log",
+							Aliases:  []string{"l", "B", "O"},
+							Usage:    "Log file with the output, and the input if it was recorded into the same file",
+							Required: true,
+						},
+						&cli.PathFlag{
+							Name:    "log-in",
+							Aliases: []string{"I"},
+							Usage:   "Log file with the input, if it was recorded into a separate file",
+						},
+						&cli.PathFlag{
+							Name:    "output",
+							Aliases: []string{"o"},
+							Usage:   "Output file (default: <log-name>.json)",
+						},
+					},
+					Action: ImportScript,
+				},
+				{
+					Name:      "strace",
+					Usage:     "Imports the read and write calls of an strace log (strace -f -tt -s 65535 -e trace=read,write -o <file>)",
+					UsageText: "recmd import strace [-o <output>] [--all-fds] [--command <command>] <file>",
+					Flags: []cli.Flag{
+						&cli.PathFlag{
+							Name:    "output",
+							Aliases: []string{"o"},
+							Usage:   "Output file (default: <file-name>.json)",
+						},
+						&cli.BoolFlag{
+							Name:  "all-fds",
+							Usage: "Keep the data of file descriptors besides 0, 1 and 2 as extra streams named fd<N>, only kept by json records",
+						},
+						&cli.StringFlag{
+							Name:  "command",
+							Usage: "Command line of the record, taken from the execve call if it was traced",
+						},
+					},
+					Action: ImportStrace,
+				},
+			},
+		},
+		{
+			Name:  "export",
+			Usage: "Converts records for other tools",
+			Subcommands: []*cli.Command{
+				{
+					Name:      "script",
+					Usage:     "Exports a record as timing and log file of util-linux script, playable with scriptreplay",
+					UsageText: "recmd export script [--format classic|advanced] [--timing <file>] [--log <file>] <file>",
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "format",
+							Usage: "Timing format: classic (stdout and stderr) or advanced (also stdin)",
+							Value: string(recmd.ScriptAdvanced),
+						},
+						&cli.PathFlag{
+							Name:    "timing",
+							Aliases: []string{"t"},
+							Usage:   "Timing file (default: <input-name>.timing)",
+						},
+						&cli.PathFlag{
+							Name:    "log",
+							Aliases: []string{"l"},
+							Usage:   "Log file (default: <input-name>.log)",
+						},
+					},
+					Action: ExportScript,
+				},
+				{
+					Name:      "html",
+					Usage:     "Exports a record as a single HTML file with a terminal player, which needs no network access",
+					UsageText: "recmd export html [-o <output>] [--title <title>] <file>",
+					Flags: []cli.Flag{
+						&cli.PathFlag{
+							Name:    "output",
+							Aliases: []string{"o"},
+							Usage:   "Output file (default: <input-name>.html)",
+						},
+						&cli.StringFlag{
+							Name:  "title",
+							Usage: "Title of the page (default: the recorded command)",
+						},
+					},
+					Action: ExportHTML,
+				},
+				{
+					Name:      "svg",
+					Usage:     "Exports a record as an animated SVG image rendered by a terminal screen model, which plays without scripts like in READMEs",
+					UsageText: "recmd export svg [command options] <file>",
+					Flags: []cli.Flag{
+						&cli.PathFlag{
+							Name:    "output",
+							Aliases: []string{"o"},
+							Usage:   "Output file (default: <input-name>.svg)",
+						},
+						&cli.StringFlag{
+							Name:  "theme",
+							Usage: "Colors: " + strings.Join(screen.ThemeNames(), ", "),
+							Value: screen.DefaultTheme,
+						},
+						&cli.Float64Flag{
+							Name:  "font-size",
+							Usage: "Font size in pixels",
+							Value: 14,
+						},
+						&cli.BoolFlag{
+							Name:  "window",
+							Usage: "Draws a window title bar",
+						},
+						&cli.StringFlag{
+							Name:  "title",
+							Usage: "Title of the window (default: the recorded command)",
+						},
+						&cli.BoolFlag{
+							Name:  "loop",
+							Usage: "Restarts the animation at the end, --loop=false keeps the last frame",
+							Value: true,
+						},
+						&cli.DurationFlag{
+							Name:  "hold",
+							Usage: "How long the last frame is shown before the animation restarts",
+							Value: 2 * time.Second,
+						},
+						&cli.Float64Flag{
+							Name:  "speed",
+							Usage: "Divides all delays, 2 plays twice as fast",
+							Value: 1,
+						},
+						&cli.DurationFlag{
+							Name:  "idle-limit",
+							Usage: "Shortens delays longer than the limit to the limit, like 2s",
+						},
+						&cli.IntFlag{
+							Name:  "columns",
+							Usage: "Width of the screen, 0 uses the recorded size or 80",
+						},
+						&cli.IntFlag{
+							Name:  "rows",
+							Usage: "Height of the screen, 0 uses the recorded size or 24",
+						},
+					},
+					Action: ExportSVG,
+				},
+				{
+					Name:      "gif",
+					Usage:     "Exports a record as an animated GIF rendered by a terminal screen model with a bitmap font",
+					UsageText: "recmd export gif [command options] <file>",
+					Flags: []cli.Flag{
+						&cli.PathFlag{
+							Name:    "output",
+							Aliases: []string{"o"},
+							Usage:   "Output file (default: <input-name>.gif)",
+						},
+						&cli.StringFlag{
+							Name:  "theme",
+							Usage: "Colors: " + strings.Join(screen.ThemeNames(), ", "),
+							Value: screen.DefaultTheme,
+						},
+						&cli.IntFlag{
+							Name:  "scale",
+							Usage: "Enlarges the 7x13 pixel font by the factor",
+							Value: 1,
+						},
+						&cli.Float64Flag{
+							Name:  "fps",
+							Usage: "Maximum frames per second, faster changes are merged, at most 50",
+							Value: 20,
+						},
+						&cli.BoolFlag{
+							Name:  "loop",
+							Usage: "Restarts the animation at the end, --loop=false keeps the last frame",
+							Value: true,
+						},
+						&cli.DurationFlag{
+							Name:  "hold",
+							Usage: "How long the last frame is shown before the animation restarts",
+							Value: 2 * time.Second,
+						},
+						&cli.Float64Flag{
+							Name:  "speed",
+							Usage: "Divides all delays, 2 plays twice as fast",
+							Value: 1,
+						},
+						&cli.DurationFlag{
+							Name:  "idle-limit",
+							Usage: "Shortens delays longer than the limit to the limit, like 2s",
+						},
+						&cli.IntFlag{
+							Name:  "columns",
+							Usage: "Width of the screen, 0 uses the recorded size or 80",
+						},
+						&cli.IntFlag{
+							Name:  "rows",
+							Usage: "Height of the screen, 0 uses the recorded size or 24",
+						},
+					},
+					Action: ExportGIF,
+				},
+				{
+					Name:      "srt",
+					Usage:     "Exports the output lines of a record as SubRip subtitles, to caption videos of the recorded terminal",
+					UsageText: "recmd export srt [command options] <file>",
+					Flags:     subtitleFlags(recmd.SubtitleSRT),
+					Action:    ExportSubtitles,
+				},
+				{
+					Name:      "vtt",
+					Usage:     "Exports the output lines of a record as WebVTT subtitles, to caption videos of the recorded terminal",
+					UsageText: "recmd export vtt [command options] <file>",
+					Flags:     subtitleFlags(recmd.SubtitleVTT),
+					Action:    ExportSubtitles,
+				},
+				{
+					Name:      "trace",
+					Usage:     "Exports a record as Trace Event Format json with tracks per stream and process, to analyse it in Perfetto or chrome://tracing",
+					UsageText: "recmd export trace [command options] <file>",
+					Flags: []cli.Flag{
+						&cli.PathFlag{
+							Name:    "output",
+							Aliases: []string{"o"},
+							Usage:   "Output file (default: <input-name>.trace.json)",
+						},
+						&cli.BoolFlag{
+							Name:  "slices",
+							Usage: "Show chunks as slices lasting until the next chunk of their stream instead of instants",
+						},
+					},
+					Action: ExportTrace,
+				},
+				{
+					Name:      "csv",
+					Usage:     "Exports the chunks, lines or time buckets of a record as a CSV or TSV table, to analyse it in pandas or spreadsheets",
+					UsageText: "recmd export csv [command options] <file>",
+					Flags: []cli.Flag{
+						&cli.PathFlag{
+							Name:    "output",
+							Aliases: []string{"o"},
+							Usage:   "Output file (default: <input-name>.csv or .tsv)",
+						},
+						&cli.StringSliceFlag{
+							Name:    "stream",
+							Aliases: []string{"s"},
+							Usage:   "Only export the given streams (out, in, err or extra streams like fd3), can be repeated",
+						},
+						&cli.StringFlag{
+							Name:  "rows",
+							Usage: "What a row is: chunks, lines (split at line feeds) or buckets (chunks and bytes per stream and --bucket)",
+							Value: string(recmd.TableChunks),
+						},
+						&cli.DurationFlag{
+							Name:  "bucket",
+							Usage: "Duration of a time bucket for --rows buckets",
+							Value: time.Second,
+						},
+						&cli.BoolFlag{
+							Name:  "base64",
+							Usage: "Export the data base64 encoded instead of escaped text",
+						},
+						&cli.BoolFlag{
+							Name:  "tsv",
+							Usage: "Separate the fields with tabs",
+						},
+					},
+					Action: ExportCSV,
+				},
+			},
+		},
 	}
 
 	err := app.Run(os.Args)
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scaxyz/recmd"
+	"github.com/urfave/cli/v2"
+)
+
+func MarkAdd(ctx *cli.Context) error {
+	if ctx.NArg() != 3 {
+		return fmt.Errorf("expected <file> <offset> <label>")
+	}
+
+	recordFile := ctx.Args().Get(0)
+
+	offset, err := parseOffset(ctx.Args().Get(1))
+	if err != nil {
+		return err
+	}
+
+	label := ctx.Args().Get(2)
+	if strings.TrimSpace(label) == "" {
+		return fmt.Errorf("empty label")
+	}
+
+	record, err := loadRecord(ctx, recordFile)
+	if err != nil {
+		return err
+	}
+
+	// the record is written back in the encoding it is stored in, whatever its extension says
+	encoding, err := detectFileEncoding(ctx, recordFile)
+	if err != nil {
+		return err
+	}
+	if !encoding.StoresMarkers() {
+		return fmt.Errorf("%s records can't store markers, convert the record to another encoding first", encoding)
+	}
+
+	record.AddMarker(recmd.Marker{Offset: offset, Label: label})
+
+	key, err := outputKey(ctx, recordFile)
+	if err != nil {
+		return err
+	}
+
+	return writeRecordAs(recordFile, record, encoding, key)
+}
+
+func MarkList(ctx *cli.Context) error {
+	record, err := loadRecord(ctx, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	for _, marker := range record.Markers() {
+		fmt.Printf("%s\t%s\n", marker.Offset, marker.Label)
+	}
+
+	return nil
+}
+
+// parseOffset parses a duration like "1m2.5s" or a plain number of seconds.
+func parseOffset(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+
+	offset, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid offset: %q", value)
+	}
+
+	return offset, nil
+}
@@ -1,11 +1,11 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"os/exec"
 	"strings"
 	"text/template"
 	"time"
@@ -38,7 +38,53 @@ func Record(ctx *cli.Context) error {
 
 	fmt.Printf("Recording: '%s'\n", strings.Join(commands, " "))
 
-	recorder := recmd.NewRecorder()
+	options := []recmd.RecorderOption{recmd.WithMarkerSequences()}
+
+	if ctx.IsSet("marker-key") {
+		key, err := parseKey(ctx.String("marker-key"))
+		if err != nil {
+			return err
+		}
+		options = append(options, recmd.WithMarkerKey(key))
+	}
+
+	if ctx.Bool("compact") {
+		options = append(options, recmd.WithCompaction(recmd.DefaultCompactOptions))
+	}
+
+	for _, entry := range ctx.StringSlice("meta") {
+		key, value, found := strings.Cut(entry, "=")
+		if !found {
+			return fmt.Errorf("invalid metadata: %q, expected key=value", entry)
+		}
+		options = append(options, recmd.WithMetadata(key, value))
+	}
+
+	if ctx.Bool("redact") || ctx.IsSet("redact-rule") || ctx.IsSet("redact-env") {
+		rules, err := redactRules(ctx, "redact-", ctx.Bool("redact"))
+		if err != nil {
+			return err
+		}
+		options = append(options, recmd.WithRedaction(&recmd.Redactor{Rules: rules}))
+	}
+
+	var key *recmd.Key
+	if ctx.Bool("encrypt") {
+		var err error
+		key, err = keyFromContext(ctx)
+		if err != nil {
+			return err
+		}
+		if key == nil {
+			return fmt.Errorf("--encrypt requires --passphrase or --key-file")
+		}
+	}
+
+	if recmd.EncodingFromPath(ctx.Path("output")) == recmd.EncodingNDJSON {
+		return recordStream(ctx, commands, input, options, key, now)
+	}
+
+	recorder := recmd.NewRecorder(options...)
 
 	record, err := recorder.Record(commands[0], input, commands[1:]...)
 	if err != nil {
@@ -47,18 +93,42 @@ func Record(ctx *cli.Context) error {
 
 	var finalRecord recmd.Record = record
 	if ctx.Bool("save-with-plain-text") {
-		finalRecord, err = finalRecord.ConvertTo(recmd.FormatString)
+		finalRecord, err = finalRecord.ConvertTo(recmd.FormatAuto)
 		if err != nil {
 			return err
 		}
 	}
 
-	recordJSON, err := json.Marshal(finalRecord)
+	outputFilePath, err := buildRecordPath(ctx, finalRecord, now)
 	if err != nil {
 		return err
 	}
 
-	outputFilePath := buildOutputFilePath(finalRecord, ctx.Path("output"), now.Format(ctx.String("time-format")))
+	err = writeRecord(outputFilePath, finalRecord, key)
+	if err != nil {
+		return err
+	}
+
+	log.Println("wrote recording to " + outputFilePath)
+
+	return nil
+}
+
+// recordStream records the command while streaming it as ndjson into the output file.
+func recordStream(ctx *cli.Context, commands []string, input io.Reader, options []recmd.RecorderOption, key *recmd.Key, now time.Time) error {
+	if ctx.Bool("compact") || ctx.Bool("save-with-plain-text") {
+		return fmt.Errorf("--compact and --save-with-plain-text are not supported for ndjson output")
+	}
+	if ctx.Bool("redact") || ctx.IsSet("redact-rule") || ctx.IsSet("redact-env") {
+		return fmt.Errorf("redaction is not supported for ndjson output, which is written while recording")
+	}
+
+	// the record only exists after recording, so the path is built from the command
+	preview := &recmd.ByteRecord{Cmd: exec.Command(commands[0], commands[1:]...).String()}
+	outputFilePath, err := buildRecordPath(ctx, preview, now)
+	if err != nil {
+		return err
+	}
 
 	outputFile, err := os.Create(outputFilePath)
 	if err != nil {
@@ -66,14 +136,47 @@ func Record(ctx *cli.Context) error {
 	}
 	defer outputFile.Close()
 
-	_, err = outputFile.Write(recordJSON)
+	writer, err := newRecordWriter(outputFile, outputFilePath, key)
+	if err != nil {
+		return err
+	}
+
+	recorder := recmd.NewRecorder(append(options, recmd.WithNDJSONStream(writer))...)
+
+	_, err = recorder.Record(commands[0], input, commands[1:]...)
+	if err != nil {
+		return err
+	}
+
+	err = writer.Close()
 	if err != nil {
 		return err
 	}
 
 	log.Println("wrote recording to " + outputFilePath)
 
-	return nil
+	return outputFile.Close()
+}
+
+// buildRecordPath builds the output path from the template and adds the extensions of the chosen compression and encryption.
+func buildRecordPath(ctx *cli.Context, record recmd.Record, now time.Time) (string, error) {
+	outputFilePath := recmd.TrimEncryptedExtension(buildOutputFilePath(record, ctx.Path("output"), now.Format(ctx.String("time-format"))))
+
+	if ctx.IsSet("compress") {
+		compression, err := recmd.ParseCompression(ctx.String("compress"))
+		if err != nil {
+			return "", err
+		}
+		if recmd.CompressionFromPath(outputFilePath) != compression {
+			outputFilePath += compression.Extension()
+		}
+	}
+
+	if ctx.Bool("encrypt") {
+		outputFilePath += recmd.EncryptedExtension
+	}
+
+	return outputFilePath, nil
 }
 
 func buildOutputFilePath(record recmd.Record, templateStr string, time string) string {
@@ -95,3 +198,17 @@ func buildOutputFilePath(record recmd.Record, templateStr string, time string) s
 	return builder.String()
 
 }
+
+// parseKey parses a single character or a control key in caret notation like "^]".
+func parseKey(key string) (byte, error) {
+	switch {
+	case len(key) == 1:
+		return key[0], nil
+	case len(key) == 2 && key[0] == '^' && key[1] >= '@' && key[1] <= '_':
+		return key[1] - '@', nil
+	case len(key) == 2 && key[0] == '^' && key[1] >= 'a' && key[1] <= 'z':
+		return key[1] - 'a' + 1, nil
+	default:
+		return 0, fmt.Errorf("invalid key: %q, expected a single character or a control key like '^]'", key)
+	}
+}
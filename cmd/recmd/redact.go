@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/scaxyz/recmd"
+	"github.com/urfave/cli/v2"
+)
+
+func Redact(ctx *cli.Context) error {
+	recordFile := ctx.Args().First()
+
+	rules, err := redactRules(ctx, "", !ctx.Bool("no-token-rules"))
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return fmt.Errorf("no rules, use --rule or --env")
+	}
+
+	record, err := loadRecord(ctx, recordFile)
+	if err != nil {
+		return err
+	}
+
+	redactor := &recmd.Redactor{Rules: rules, Mask: ctx.String("mask")}
+	if ctx.Bool("interactive") {
+		redactor.Review = reviewHit(bufio.NewReader(os.Stdin))
+	}
+
+	redacted, hits, err := redactor.Redact(record)
+	if err != nil {
+		return err
+	}
+
+	if len(hits) == 0 {
+		fmt.Println("nothing to redact")
+		return nil
+	}
+	fmt.Printf("redacted %d secrets: %s\n", len(hits), redacted.Metadata()[recmd.MetadataRedacted])
+
+	return writeEdited(ctx, "redacted", redacted, recordFile)
+}
+
+// redactRules builds the rules of the --<prefix>rule and --<prefix>env flags, adding the token rules if tokens is set.
+func redactRules(ctx *cli.Context, prefix string, tokens bool) ([]recmd.RedactRule, error) {
+	var rules []recmd.RedactRule
+	if tokens {
+		rules = append(rules, recmd.TokenRedactRules...)
+	}
+
+	for _, entry := range ctx.StringSlice(prefix + "rule") {
+		name, pattern, found := strings.Cut(entry, "=")
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid rule: %q, expected name=regex", entry)
+		}
+		rule, err := recmd.NewRedactRule(name, pattern)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	for _, name := range ctx.StringSlice(prefix + "env") {
+		rule, err := recmd.EnvRedactRule(name)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// reviewHit asks on the terminal whether a hit should be redacted.
+func reviewHit(input *bufio.Reader) func(recmd.RedactHit) bool {
+	decision := ""
+	return func(hit recmd.RedactHit) bool {
+		switch decision {
+		case "all":
+			return true
+		case "done":
+			return false
+		}
+
+		location := "command"
+		if hit.Stream != "" {
+			location = fmt.Sprintf("%s +%s", hit.Stream, recmd.FormatOffset(hit.Offset))
+		}
+		fmt.Printf("\n%s  rule %s\n  line:   %s\n  secret: %s\n", location, hit.Rule, hit.Line, hit.Text)
+
+		for {
+			fmt.Print("redact? [Y]es, [n]o, [a]ll remaining, [d]one (keep remaining): ")
+			answer, err := input.ReadString('\n')
+			if err != nil && answer == "" {
+				// no more input, keep redacting to be safe
+				decision = "all"
+				return true
+			}
+			switch strings.ToLower(strings.TrimSpace(answer)) {
+			case "", "y", "yes":
+				return true
+			case "n", "no":
+				return false
+			case "a", "all":
+				decision = "all"
+				return true
+			case "d", "done":
+				decision = "done"
+				return false
+			}
+		}
+	}
+}
@@ -1,13 +1,13 @@
 package main
 
 import (
-	"encoding/json"
+	"bufio"
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/scaxyz/recmd"
-	"github.com/tidwall/gjson"
 	"github.com/urfave/cli/v2"
 )
 
@@ -15,12 +15,91 @@ func Replay(ctx *cli.Context) error {
 
 	recordFile := ctx.Args().First()
 
-	file, err := os.Open(recordFile)
+	encoding, err := detectFileEncoding(ctx, recordFile)
+	if err != nil {
+		return err
+	}
+
+	if encoding == recmd.EncodingNDJSON {
+		return replayStream(ctx, recordFile)
+	}
+
+	if ctx.Bool("follow") {
+		return fmt.Errorf("--follow is only supported for ndjson records")
+	}
+
+	if encoding == recmd.EncodingBinary {
+		replayed, err := replayBinary(ctx, recordFile)
+		if replayed || err != nil {
+			return err
+		}
+	}
+
+	record, err := loadRecord(ctx, recordFile)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Replaying: ", record.Command())
+
+	reader := record.Reader()
+
+	err = replay(ctx, reader, record.Markers())
+	if err != nil {
+		return err
+	}
+
+	exitCode := record.ExitCode()
+	if ctx.IsSet("exit-code") {
+		exitCode = ctx.Int("exit-code")
+	}
+
+	os.Exit(exitCode)
+
+	return nil
+}
+
+// replayStream replays an ndjson record while reading it.
+func replayStream(ctx *cli.Context, recordFile string) error {
+	var markers []recmd.Marker
+	if ctx.IsSet("from-marker") || ctx.IsSet("to-marker") {
+		if ctx.Bool("follow") {
+			return fmt.Errorf("--follow can't be combined with --from-marker or --to-marker")
+		}
+		var err error
+		markers, err = scanMarkers(ctx, recordFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	if ctx.Bool("follow") {
+		encrypted, err := isEncryptedFile(recordFile)
+		if err != nil {
+			return err
+		}
+		if encrypted {
+			return fmt.Errorf("--follow is not supported for encrypted records")
+		}
+	}
+
+	file, opened, err := openRecordFile(ctx, recordFile)
 	if err != nil {
 		return err
 	}
 
-	jsonData, err := io.ReadAll(file)
+	reader, err := recmd.NewStreamReader(opened)
+	if err != nil {
+		return err
+	}
+
+	if ctx.Bool("follow") {
+		reader.Follow()
+	}
+
+	fmt.Println("Replaying: ", reader.Command())
+
+	err = replay(ctx, reader, markers)
 	if err != nil {
 		return err
 	}
@@ -31,33 +110,75 @@ func Replay(ctx *cli.Context) error {
 		return err
 	}
 
-	format := gjson.Get(string(jsonData), "format")
-	var record recmd.Record
+	exitCode := reader.ExitCode()
+	if ctx.IsSet("exit-code") {
+		exitCode = ctx.Int("exit-code")
+	}
+
+	os.Exit(exitCode)
+
+	return nil
+}
 
-	switch format.String() {
-	case string(recmd.FormatBase64):
-		record = &recmd.ByteRecord{}
-	case string(recmd.FormatString):
-		record = &recmd.StringRecord{}
-	default:
-		return fmt.Errorf("unknown format: %s", format.String())
+// replayBinary replays an uncompressed binary record while reading it, seeking to --from-marker with its index.
+// It reports false for compressed or encrypted records, which have to be loaded completely.
+func replayBinary(ctx *cli.Context, recordFile string) (bool, error) {
+	file, err := os.Open(recordFile)
+	if err != nil {
+		return false, err
 	}
 
-	err = json.Unmarshal(jsonData, record)
+	reader, err := recmd.NewBinaryStreamReader(file)
 	if err != nil {
-		return err
+		file.Close()
+		return false, nil
 	}
 
-	fmt.Println("Replaying: ", record.Command())
+	fmt.Println("Replaying: ", reader.Command())
 
-	reader := record.Reader()
+	err = replay(ctx, reader, reader.Markers())
+	if err != nil {
+		file.Close()
+		return true, err
+	}
 
+	// no defer since we are using os.Exit at the and
+	err = file.Close()
+	if err != nil {
+		return true, err
+	}
+
+	exitCode := reader.ExitCode()
+	if ctx.IsSet("exit-code") {
+		exitCode = ctx.Int("exit-code")
+	}
+
+	os.Exit(exitCode)
+
+	return true, nil
+}
+
+// replay applies the replay flags to the reader and copies it to stdout.
+func replay(ctx *cli.Context, reader io.Reader, markers []recmd.Marker) error {
 	if ctx.Bool("no-delays") {
 		if r, ok := reader.(interface{ IgnoreTime() }); ok {
 			r.IgnoreTime()
 		}
 	}
 
+	timing, err := timingFromFlags(ctx)
+	if err != nil {
+		return err
+	}
+	if r, ok := reader.(interface{ SetTiming(recmd.Timing) }); ok {
+		r.SetTiming(timing)
+	}
+
+	err = applyMarkerFlags(ctx, markers, reader)
+	if err != nil {
+		return err
+	}
+
 	buffer := make([]byte, replayBufferSize)
 
 	for {
@@ -73,12 +194,86 @@ func Replay(ctx *cli.Context) error {
 
 		fmt.Print(string(data))
 	}
-	exitCode := record.ExitCode()
-	if ctx.IsSet("exit-code") {
-		exitCode = ctx.Int("exit-code")
+
+	return nil
+}
+
+func applyMarkerFlags(ctx *cli.Context, markers []recmd.Marker, reader io.Reader) error {
+	markerReader, ok := reader.(interface {
+		StartAt(offset time.Duration)
+		StopAt(offset time.Duration)
+		OnMarker(handler func(recmd.Marker))
+	})
+	if !ok {
+		return nil
 	}
 
-	os.Exit(exitCode)
+	if ctx.IsSet("from-marker") {
+		marker, err := findMarker(markers, ctx.String("from-marker"))
+		if err != nil {
+			return err
+		}
+		markerReader.StartAt(marker.Offset)
+	}
+
+	if ctx.IsSet("to-marker") {
+		marker, err := findMarker(markers, ctx.String("to-marker"))
+		if err != nil {
+			return err
+		}
+		markerReader.StopAt(marker.Offset)
+	}
+
+	if ctx.Bool("pause-at-markers") {
+		input := bufio.NewReader(os.Stdin)
+		markerReader.OnMarker(func(marker recmd.Marker) {
+			fmt.Fprintf(os.Stderr, "\n[marker %s at %s, press enter to continue]", marker.Label, marker.Offset)
+			input.ReadString('\n')
+		})
+	}
 
 	return nil
 }
+
+func findMarker(markers []recmd.Marker, label string) (recmd.Marker, error) {
+	for _, marker := range markers {
+		if marker.Label == label {
+			return marker, nil
+		}
+	}
+	return recmd.Marker{}, fmt.Errorf("marker not found: %s", label)
+}
+
+// scanMarkers reads only the markers of an ndjson record.
+func scanMarkers(ctx *cli.Context, recordFile string) ([]recmd.Marker, error) {
+	file, opened, err := openRecordFile(ctx, recordFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoder, err := recmd.NewNDJSONDecoder(opened)
+	if err != nil {
+		return nil, err
+	}
+
+	var markers []recmd.Marker
+	for {
+		entry, err := decoder.Next()
+		if err == io.EOF {
+			return markers, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if entry.Type == recmd.EntryMarker {
+			markers = append(markers, recmd.Marker{Offset: entry.Offset, Label: entry.Label})
+		}
+	}
+}
+
+// timingFromFlags reads the timing policy from the --speed and --idle-limit flags.
+func timingFromFlags(ctx *cli.Context) (recmd.Timing, error) {
+	timing := recmd.Timing{Speed: ctx.Float64("speed"), IdleLimit: ctx.Duration("idle-limit")}
+	return timing, timing.Validate()
+}
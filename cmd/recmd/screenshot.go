@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/scaxyz/recmd/screen"
+	"github.com/urfave/cli/v2"
+)
+
+func Screenshot(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("expected <file>")
+	}
+
+	at := screen.AtExit
+	if value := ctx.String("at"); value != "exit" {
+		var err error
+		at, err = parseOffset(value)
+		if err != nil {
+			return err
+		}
+	}
+
+	theme, err := screen.ParseTheme(ctx.String("theme"))
+	if err != nil {
+		return err
+	}
+
+	record, err := loadRecord(ctx, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	columns, rows := screenSize(ctx, record)
+	snapshot := screen.At(record, columns, rows, at)
+
+	var content string
+	switch ctx.String("format") {
+	case "text":
+		content = snapshot.Text() + "\n"
+	case "ansi":
+		content = snapshot.ANSI() + "\n"
+	case "html":
+		content = snapshot.HTML(&theme)
+	default:
+		return fmt.Errorf("unknown format: %s, expected text, ansi or html", ctx.String("format"))
+	}
+
+	outputPath := ctx.Path("output")
+	if strings.TrimSpace(outputPath) == "" {
+		_, err = io.WriteString(os.Stdout, content)
+		return err
+	}
+
+	err = os.WriteFile(outputPath, []byte(content), 0644)
+	if err != nil {
+		return err
+	}
+
+	log.Println("wrote screenshot to " + outputPath)
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/scaxyz/recmd"
+	"github.com/urfave/cli/v2"
+)
+
+func ImportScript(ctx *cli.Context) error {
+	timing, err := os.Open(ctx.Path("timing"))
+	if err != nil {
+		return err
+	}
+	defer timing.Close()
+
+	output, err := os.Open(ctx.Path("log"))
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	var input io.Reader
+	if ctx.IsSet("log-in") {
+		inputFile, err := os.Open(ctx.Path("log-in"))
+		if err != nil {
+			return err
+		}
+		defer inputFile.Close()
+		input = inputFile
+	}
+
+	record, err := recmd.DecodeScript(timing, output, input)
+	if err != nil {
+		return err
+	}
+
+	outputPath := ctx.Path("output")
+	if strings.TrimSpace(outputPath) == "" {
+		outputPath = importedPath(ctx.Path("log"))
+	}
+
+	err = writeRecord(outputPath, record, nil)
+	if err != nil {
+		return err
+	}
+
+	log.Println("wrote recording to " + outputPath)
+	return nil
+}
+
+func ExportScript(ctx *cli.Context) error {
+	recordFile := ctx.Args().First()
+
+	format, err := recmd.ParseScriptFormat(ctx.String("format"))
+	if err != nil {
+		return err
+	}
+
+	record, err := loadRecord(ctx, recordFile)
+	if err != nil {
+		return err
+	}
+
+	base := strings.TrimSuffix(recordFile, recordExt(recordFile))
+	timingPath, logPath := ctx.Path("timing"), ctx.Path("log")
+	if strings.TrimSpace(timingPath) == "" {
+		timingPath = base + ".timing"
+	}
+	if strings.TrimSpace(logPath) == "" {
+		logPath = base + ".log"
+	}
+
+	timing, err := os.Create(timingPath)
+	if err != nil {
+		return err
+	}
+	defer timing.Close()
+
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	err = recmd.EncodeScript(timing, logFile, record, format)
+	if err != nil {
+		return err
+	}
+
+	replayCommand := fmt.Sprintf("scriptreplay -t %s %s", timingPath, logPath)
+	if format == recmd.ScriptAdvanced {
+		replayCommand = fmt.Sprintf("scriptreplay -T %s -B %s", timingPath, logPath)
+	}
+	fmt.Printf("wrote %s and %s, play them with: %s\n", timingPath, logPath, replayCommand)
+
+	err = timing.Close()
+	if err != nil {
+		return err
+	}
+	return logFile.Close()
+}
+
+// importedPath returns the path of a record imported from the file at path: session.log -> session.json
+func importedPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + recmd.EncodingJSON.Extension()
+}
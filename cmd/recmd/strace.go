@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/scaxyz/recmd"
+	"github.com/urfave/cli/v2"
+)
+
+func ImportStrace(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("expected <strace-log>")
+	}
+	traceFile := ctx.Args().First()
+
+	file, err := os.Open(traceFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	record, err := recmd.DecodeStrace(file, ctx.Bool("all-fds"))
+	if err != nil {
+		return err
+	}
+
+	byteRecord := record.(*recmd.ByteRecord)
+	if ctx.IsSet("command") {
+		byteRecord.Cmd = ctx.String("command")
+	}
+
+	if truncated := record.Metadata()[recmd.MetadataStraceTruncated]; truncated != "" {
+		log.Printf("warning: strace truncated %s payloads, record with strace -s 65535 to keep all data", truncated)
+	}
+
+	outputPath := ctx.Path("output")
+	if strings.TrimSpace(outputPath) == "" {
+		outputPath = importedPath(traceFile)
+	}
+
+	err = writeRecord(outputPath, record, nil)
+	if err != nil {
+		return err
+	}
+
+	log.Println("wrote recording to " + outputPath)
+	return nil
+}
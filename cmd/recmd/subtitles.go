@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/scaxyz/recmd"
+	"github.com/urfave/cli/v2"
+)
+
+// ExportSubtitles exports srt or vtt files, the format is the name of the command.
+func ExportSubtitles(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("expected <file>")
+	}
+	recordFile := ctx.Args().First()
+
+	format, err := recmd.ParseSubtitleFormat(ctx.Command.Name)
+	if err != nil {
+		return err
+	}
+
+	streams, err := parseStreams(ctx.StringSlice("stream"))
+	if err != nil {
+		return err
+	}
+
+	timing, err := timingFromFlags(ctx)
+	if err != nil {
+		return err
+	}
+
+	record, err := loadRecord(ctx, recordFile)
+	if err != nil {
+		return err
+	}
+
+	cues := recmd.Cues(record, recmd.SubtitleOptions{
+		Streams:     streams,
+		Timing:      timing,
+		MinDuration: ctx.Duration("min-duration"),
+		MaxDuration: ctx.Duration("max-duration"),
+		MaxLength:   ctx.Int("max-length"),
+		MaxLines:    ctx.Int("max-lines"),
+		Labels:      ctx.Bool("labels"),
+	})
+
+	outputPath := ctx.Path("output")
+	if strings.TrimSpace(outputPath) == "" {
+		outputPath = strings.TrimSuffix(recordFile, recordExt(recordFile)) + "." + string(format)
+	}
+
+	output, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	err = recmd.EncodeSubtitles(output, cues, format)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("wrote %d cues to %s", len(cues), outputPath)
+	return output.Close()
+}
+
+// subtitleFlags are the flags of the srt and vtt exports.
+func subtitleFlags(format recmd.SubtitleFormat) []cli.Flag {
+	return []cli.Flag{
+		&cli.PathFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "Output file (default: <input-name>." + string(format) + ")",
+		},
+		&cli.StringSliceFlag{
+			Name:    "stream",
+			Aliases: []string{"s"},
+			Usage:   "Only caption the given streams (out, in, err or extra streams like fd3), can be repeated",
+		},
+		&cli.BoolFlag{
+			Name:  "labels",
+			Usage: "Prefix every line with its stream, like [stderr]",
+		},
+		&cli.DurationFlag{
+			Name:  "min-duration",
+			Usage: "How long a cue is at least shown, lines starting within this time of it are merged into it",
+			Value: time.Second,
+		},
+		&cli.DurationFlag{
+			Name:  "max-duration",
+			Usage: "How long a cue is shown at most if the next one starts later, 0 shows it until the next one",
+			Value: 5 * time.Second,
+		},
+		&cli.IntFlag{
+			Name:  "max-length",
+			Usage: "Wraps longer lines at spaces, 0 keeps lines unwrapped",
+			Value: 80,
+		},
+		&cli.IntFlag{
+			Name:  "max-lines",
+			Usage: "Number of lines of a cue, the next cue shows the last lines which appeared until it starts",
+			Value: 2,
+		},
+		&cli.Float64Flag{
+			Name:  "speed",
+			Usage: "Divides all delays, like for a video exported with --speed",
+			Value: 1,
+		},
+		&cli.DurationFlag{
+			Name:  "idle-limit",
+			Usage: "Shortens delays longer than the limit to the limit, like for a video exported with --idle-limit",
+		},
+	}
+}
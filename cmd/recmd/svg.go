@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/scaxyz/recmd"
+	"github.com/scaxyz/recmd/screen"
+	"github.com/urfave/cli/v2"
+)
+
+func ExportSVG(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("expected <file>")
+	}
+	recordFile := ctx.Args().First()
+
+	theme, err := screen.ParseTheme(ctx.String("theme"))
+	if err != nil {
+		return err
+	}
+
+	timing, err := timingFromFlags(ctx)
+	if err != nil {
+		return err
+	}
+
+	record, err := loadRecord(ctx, recordFile)
+	if err != nil {
+		return err
+	}
+
+	columns, rows := screenSize(ctx, record)
+	frames := screen.Frames(record, columns, rows, timing)
+
+	title := ctx.String("title")
+	if !ctx.IsSet("title") {
+		title = record.Command()
+	}
+
+	outputPath := ctx.Path("output")
+	if strings.TrimSpace(outputPath) == "" {
+		outputPath = strings.TrimSuffix(recordFile, recordExt(recordFile)) + ".svg"
+	}
+
+	output, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	err = screen.EncodeSVG(output, frames, screen.SVGOptions{
+		Theme:    theme,
+		FontSize: ctx.Float64("font-size"),
+		Window:   ctx.Bool("window"),
+		Title:    title,
+		Loop:     ctx.Bool("loop"),
+		Hold:     ctx.Duration("hold"),
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("wrote %d frames to %s", len(frames), outputPath)
+	return output.Close()
+}
+
+// screenSize returns the terminal size from the --columns and --rows flags, or the size stored in the record.
+func screenSize(ctx *cli.Context, record recmd.Record) (int, int) {
+	columns, rows := screen.Size(record)
+	if ctx.Int("columns") > 0 {
+		columns = ctx.Int("columns")
+	}
+	if ctx.Int("rows") > 0 {
+		rows = ctx.Int("rows")
+	}
+	return columns, rows
+}
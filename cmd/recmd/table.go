@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/scaxyz/recmd"
+	"github.com/urfave/cli/v2"
+)
+
+func ExportCSV(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("expected <file>")
+	}
+	recordFile := ctx.Args().First()
+
+	rows, err := recmd.ParseTableRows(ctx.String("rows"))
+	if err != nil {
+		return err
+	}
+
+	streams, err := parseStreams(ctx.StringSlice("stream"))
+	if err != nil {
+		return err
+	}
+
+	record, err := loadRecord(ctx, recordFile)
+	if err != nil {
+		return err
+	}
+
+	options := recmd.TableOptions{
+		Streams: streams,
+		Rows:    rows,
+		Bucket:  ctx.Duration("bucket"),
+		Base64:  ctx.Bool("base64"),
+	}
+	ext := ".csv"
+	if ctx.Bool("tsv") {
+		options.Comma = '\t'
+		ext = ".tsv"
+	}
+
+	outputPath := ctx.Path("output")
+	if strings.TrimSpace(outputPath) == "" {
+		outputPath = strings.TrimSuffix(recordFile, recordExt(recordFile)) + ext
+	}
+
+	// the table is built first so invalid options like too many buckets don't leave an empty file
+	var table bytes.Buffer
+	err = recmd.EncodeTable(&table, record, options)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(outputPath, table.Bytes(), 0644)
+	if err != nil {
+		return err
+	}
+
+	log.Println("wrote " + string(rows) + " to " + outputPath)
+	return nil
+}
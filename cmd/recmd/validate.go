@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/scaxyz/recmd"
+	"github.com/urfave/cli/v2"
+)
+
+func Validate(ctx *cli.Context) error {
+	if ctx.NArg() == 0 {
+		return fmt.Errorf("no files specified")
+	}
+
+	failed := false
+	for _, recordFile := range ctx.Args().Slice() {
+		problems, err := validateFile(ctx, recordFile)
+		if err != nil {
+			fmt.Printf("%s: error: %s\n", recordFile, err)
+			failed = true
+			continue
+		}
+
+		if len(problems) == 0 {
+			fmt.Printf("%s: ok\n", recordFile)
+			continue
+		}
+
+		for _, problem := range problems {
+			fmt.Printf("%s: %s\n", recordFile, problem)
+		}
+		if recmd.HasErrors(problems) {
+			failed = true
+		}
+	}
+
+	if failed {
+		return cli.Exit("", 1)
+	}
+
+	return nil
+}
+
+func validateFile(ctx *cli.Context, recordFile string) ([]recmd.Problem, error) {
+	key, err := keyFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(recordFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return recmd.ValidateReader(file, recmd.WithKey(key))
+}
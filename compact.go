@@ -0,0 +1,95 @@
+package recmd
+
+import "time"
+
+// CompactOptions configures Compact.
+type CompactOptions struct {
+	// MergeWithin merges a chunk into the previous one of the same stream if it starts closer than this after the
+	// first chunk merged into it, so a merged chunk never spans more than this.
+	MergeWithin time.Duration
+	// Resolution rounds all offsets down to a multiple of it.
+	Resolution time.Duration
+}
+
+// DefaultCompactOptions merges chunks within 10ms and rounds offsets to milliseconds.
+var DefaultCompactOptions = CompactOptions{
+	MergeWithin: 10 * time.Millisecond,
+	Resolution:  time.Millisecond,
+}
+
+// Compact reduces the number of chunks of a record by merging chunks and quantising offsets.
+//
+// Chunks are only merged if no chunk of another stream lies between them, and offsets are never
+// moved before the offset of the preceding chunk, so the order of all bytes is kept.
+// If rounding would give chunks of different streams the same offset, the later one is placed 1ns after.
+func Compact(record Record, options CompactOptions) (Record, error) {
+	compacted := &ByteRecord{
+		JsonFormat: FormatBase64,
+		Cmd:        record.Command(),
+		ExitC:      record.ExitCode(),
+		Out:        make(map[time.Duration][]byte),
+		In:         make(map[time.Duration][]byte),
+		Err:        make(map[time.Duration][]byte),
+		Meta:       cloneMetadata(record.Metadata()),
+	}
+
+	// moved maps the original offsets to the compacted ones for the strace processes in the metadata
+	moved := make(map[time.Duration]time.Duration)
+	var previous *Event
+	// groupStart is the original offset of the first chunk merged into previous, measuring from it instead of
+	// the last merged chunk keeps a steady stream of updates like a progress bar from collapsing into one chunk
+	var groupStart time.Duration
+	for _, event := range Events(record, RecordStreams(record)...) {
+		if previous != nil && previous.Stream == event.Stream && event.Offset-groupStart < options.MergeWithin {
+			appendChunk(compacted.chunks(event.Stream), previous.Offset, event.Data)
+			if _, ok := moved[event.Offset]; !ok {
+				moved[event.Offset] = previous.Offset
+			}
+			continue
+		}
+
+		offset := quantise(event.Offset, options.Resolution)
+		if previous != nil && offset <= previous.Offset {
+			offset = previous.Offset
+			if previous.Stream != event.Stream {
+				offset++
+			}
+		}
+
+		appendChunk(compacted.chunks(event.Stream), offset, event.Data)
+		if _, ok := moved[event.Offset]; !ok {
+			moved[event.Offset] = offset
+		}
+		previous = &Event{Offset: offset, Stream: event.Stream}
+		groupStart = event.Offset
+	}
+
+	for _, marker := range record.Markers() {
+		compacted.AddMarker(Marker{Offset: quantise(marker.Offset, options.Resolution), Label: marker.Label})
+	}
+
+	remapStraceProcesses(compacted, record, func(offset time.Duration) (time.Duration, bool) {
+		if compactedOffset, ok := moved[offset]; ok {
+			return compactedOffset, true
+		}
+		return quantise(offset, options.Resolution), true
+	})
+
+	return compacted.ConvertTo(record.Format())
+}
+
+func quantise(offset time.Duration, resolution time.Duration) time.Duration {
+	if resolution <= 0 {
+		return offset
+	}
+	return offset - offset%resolution
+}
+
+// ChunkCount returns the number of chunks of all streams.
+func ChunkCount(record Record) int {
+	count := 0
+	for _, stream := range RecordStreams(record) {
+		count += len(StreamData(record, stream))
+	}
+	return count
+}
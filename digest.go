@@ -0,0 +1,147 @@
+package recmd
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Digests are stored in the metadata of a record as "sha256:<hex>":
+//
+//	digest.out, digest.in, digest.err  the chunks of the stream in offset order, each as
+//	                                   offset (int64) | length (uint64) | data, big endian
+//	digest.record                      command, exit code, the stream digests, markers and
+//	                                   all metadata except the digests, length prefixed
+//
+// Any change to the record, including edits like trimming or adding a marker, changes the record digest.
+
+const (
+	// MetadataDigestPrefix is the prefix of all metadata keys holding digests.
+	MetadataDigestPrefix = "digest."
+	// MetadataDigestRecord is the metadata key of the digest over the whole record.
+	MetadataDigestRecord = MetadataDigestPrefix + "record"
+
+	digestAlgorithm = "sha256:"
+)
+
+// ErrNoDigests is returned when verifying a record without stored digests.
+var ErrNoDigests = errors.New("record has no digests")
+
+// DigestKey returns the metadata key of the digest of the stream.
+func DigestKey(stream Stream) string {
+	return MetadataDigestPrefix + string(stream)
+}
+
+// Digests computes the digests of the record, keyed by their metadata key.
+func Digests(record Record) map[string]string {
+	digests := make(map[string]string)
+
+	for _, stream := range Streams {
+		h := sha256.New()
+		for _, event := range Events(record, stream) {
+			writeUint(h, uint64(event.Offset))
+			writeUint(h, uint64(len(event.Data)))
+			h.Write(event.Data)
+		}
+		digests[DigestKey(stream)] = encodeDigest(h)
+	}
+
+	h := sha256.New()
+	writeField(h, "recmd record digest v1")
+	writeField(h, record.Command())
+	writeField(h, strconv.Itoa(record.ExitCode()))
+	for _, stream := range Streams {
+		writeField(h, digests[DigestKey(stream)])
+	}
+
+	markers := record.Markers()
+	writeUint(h, uint64(len(markers)))
+	for _, marker := range markers {
+		writeUint(h, uint64(marker.Offset))
+		writeField(h, marker.Label)
+	}
+
+	metadata := record.Metadata()
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		if !strings.HasPrefix(key, MetadataDigestPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	writeUint(h, uint64(len(keys)))
+	for _, key := range keys {
+		writeField(h, key)
+		writeField(h, metadata[key])
+	}
+
+	digests[MetadataDigestRecord] = encodeDigest(h)
+
+	return digests
+}
+
+// AddDigests stores the digests of the record in its metadata, replacing existing ones.
+func AddDigests(record Record) {
+	for key, digest := range Digests(record) {
+		record.SetMetadata(key, digest)
+	}
+}
+
+// DigestCheck is the result of comparing a stored digest with the recomputed one.
+type DigestCheck struct {
+	Key      string `json:"key"`
+	Stored   string `json:"stored"`
+	Computed string `json:"computed"`
+}
+
+// OK reports whether the stored digest matches the computed one.
+func (c DigestCheck) OK() bool {
+	return c.Stored == c.Computed
+}
+
+// VerifyDigests recomputes the digests of the record and compares them with the stored ones.
+// Missing digests are reported as mismatches, a record without any digest results in ErrNoDigests.
+func VerifyDigests(record Record) ([]DigestCheck, error) {
+	metadata := record.Metadata()
+
+	found := false
+	for key := range metadata {
+		if strings.HasPrefix(key, MetadataDigestPrefix) {
+			found = true
+		}
+	}
+	if !found {
+		return nil, ErrNoDigests
+	}
+
+	computed := Digests(record)
+
+	var checks []DigestCheck
+	for _, stream := range Streams {
+		key := DigestKey(stream)
+		checks = append(checks, DigestCheck{Key: key, Stored: metadata[key], Computed: computed[key]})
+	}
+	checks = append(checks, DigestCheck{Key: MetadataDigestRecord, Stored: metadata[MetadataDigestRecord], Computed: computed[MetadataDigestRecord]})
+
+	return checks, nil
+}
+
+func encodeDigest(h hash.Hash) string {
+	return digestAlgorithm + hex.EncodeToString(h.Sum(nil))
+}
+
+func writeUint(h hash.Hash, value uint64) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], value)
+	h.Write(buf[:])
+}
+
+func writeField(h hash.Hash, value string) {
+	writeUint(h, uint64(len(value)))
+	h.Write([]byte(value))
+}
@@ -0,0 +1,221 @@
+package recmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Duration returns the offset of the last chunk, of any stream, or marker of the record.
+func Duration(record Record) time.Duration {
+	var duration time.Duration
+	for _, stream := range RecordStreams(record) {
+		for offset := range StreamData(record, stream) {
+			if offset > duration {
+				duration = offset
+			}
+		}
+	}
+	for _, marker := range record.Markers() {
+		if marker.Offset > duration {
+			duration = marker.Offset
+		}
+	}
+	return duration
+}
+
+// Trim keeps only the part of the record between from (inclusive) and to (exclusive).
+// Offsets are moved so that from becomes the start of the record.
+// A negative to keeps everything after from.
+func Trim(record Record, from time.Duration, to time.Duration) (Record, error) {
+	if to >= 0 && to < from {
+		return nil, fmt.Errorf("invalid range: %s-%s", from, to)
+	}
+
+	trimmed := remap(record, func(offset time.Duration) (time.Duration, bool) {
+		if offset < from || (to >= 0 && offset >= to) {
+			return 0, false
+		}
+		return offset - from, true
+	})
+	shiftStartTime(trimmed, from)
+
+	return trimmed.ConvertTo(record.Format())
+}
+
+// Cut removes the part of the record between from (inclusive) and to (exclusive).
+// Everything after the cut is moved forward by its length.
+func Cut(record Record, from time.Duration, to time.Duration) (Record, error) {
+	if to < from {
+		return nil, fmt.Errorf("invalid range: %s-%s", from, to)
+	}
+
+	cut := remap(record, func(offset time.Duration) (time.Duration, bool) {
+		switch {
+		case offset < from:
+			return offset, true
+		case offset < to:
+			return 0, false
+		default:
+			return offset - (to - from), true
+		}
+	})
+
+	return cut.ConvertTo(record.Format())
+}
+
+// Concat joins the records, each one starting gap after the end of the previous one.
+// The result uses the format of the first record and the exit code of the last one,
+// metadata of earlier records takes precedence.
+func Concat(gap time.Duration, records ...Record) (Record, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no records to concat")
+	}
+
+	joined := &ByteRecord{
+		JsonFormat: FormatBase64,
+		Out:        make(map[time.Duration][]byte),
+		In:         make(map[time.Duration][]byte),
+		Err:        make(map[time.Duration][]byte),
+	}
+
+	commands := make([]string, len(records))
+	var start time.Duration
+	for i, record := range records {
+		if i > 0 {
+			start = Duration(joined) + gap
+		}
+		insertRecord(joined, record, start)
+		commands[i] = record.Command()
+		joined.ExitC = record.ExitCode()
+	}
+	joined.Cmd = strings.Join(commands, "; ")
+
+	return joined.ConvertTo(records[0].Format())
+}
+
+// Splice inserts another record into the record at the given offset.
+// Everything of the record at or after the offset is moved back by the length of the inserted record plus gap.
+// Command, exit code and metadata of the record are kept.
+func Splice(record Record, at time.Duration, insert Record, gap time.Duration) (Record, error) {
+	if at < 0 {
+		return nil, fmt.Errorf("invalid offset: %s", at)
+	}
+
+	shift := Duration(insert) + gap
+	spliced := remap(record, func(offset time.Duration) (time.Duration, bool) {
+		if offset < at {
+			return offset, true
+		}
+		return offset + shift, true
+	})
+
+	// the moved chunks are put back after inserting, so the inserted data comes first where both meet
+	moved := make(map[Stream]map[time.Duration][]byte)
+	for _, stream := range RecordStreams(spliced) {
+		chunks := spliced.chunks(stream)
+		moved[stream] = make(map[time.Duration][]byte)
+		for offset, data := range chunks {
+			if offset >= at+shift {
+				moved[stream][offset] = data
+				delete(chunks, offset)
+			}
+		}
+	}
+	insertRecord(spliced, insert, at)
+	for stream, chunks := range moved {
+		for offset, data := range chunks {
+			appendChunk(spliced.chunks(stream), offset, data)
+		}
+	}
+
+	return spliced.ConvertTo(record.Format())
+}
+
+// remap copies the record while moving every chunk and marker with the mapping function.
+// Chunks and markers for which mapping returns false are dropped.
+func remap(record Record, mapping func(time.Duration) (time.Duration, bool)) *ByteRecord {
+	remapped := &ByteRecord{
+		JsonFormat: FormatBase64,
+		Cmd:        record.Command(),
+		ExitC:      record.ExitCode(),
+		Meta:       cloneMetadata(record.Metadata()),
+	}
+
+	for _, stream := range RecordStreams(record) {
+		chunks := remapped.chunks(stream)
+		for _, offset := range sortedOffsets(StreamData(record, stream)) {
+			if newOffset, ok := mapping(offset); ok {
+				appendChunk(chunks, newOffset, StreamData(record, stream)[offset])
+			}
+		}
+	}
+
+	for _, marker := range record.Markers() {
+		if newOffset, ok := mapping(marker.Offset); ok {
+			remapped.AddMarker(Marker{Offset: newOffset, Label: marker.Label})
+		}
+	}
+
+	remapStraceProcesses(remapped, record, mapping)
+
+	return remapped
+}
+
+// insertRecord adds all chunks, markers and missing metadata of record to target, moved by start.
+// The strace processes of record are moved by start as well, and the digests of target are dropped
+// as they no longer match.
+func insertRecord(target *ByteRecord, record Record, start time.Duration) {
+	for _, stream := range RecordStreams(record) {
+		chunks := StreamData(record, stream)
+		for _, offset := range sortedOffsets(chunks) {
+			appendChunk(target.chunks(stream), offset+start, chunks[offset])
+		}
+	}
+
+	for _, marker := range record.Markers() {
+		target.AddMarker(Marker{Offset: marker.Offset + start, Label: marker.Label})
+	}
+
+	for key, value := range record.Metadata() {
+		if strings.HasPrefix(key, MetadataStraceProcessPrefix) {
+			continue
+		}
+		if _, ok := target.Metadata()[key]; !ok {
+			target.SetMetadata(key, value)
+		}
+	}
+
+	known := make(map[int]bool)
+	for _, process := range StraceProcesses(target) {
+		known[process.PID] = true
+	}
+	for _, process := range StraceProcesses(record) {
+		if !known[process.PID] {
+			setStraceProcess(target, process.moved(start))
+		}
+	}
+
+	for key := range target.Meta {
+		if strings.HasPrefix(key, MetadataDigestPrefix) {
+			delete(target.Meta, key)
+		}
+	}
+}
+
+// appendChunk stores the data at the offset, appending it to an existing chunk at the same offset.
+func appendChunk(chunks map[time.Duration][]byte, offset time.Duration, data []byte) {
+	existing := chunks[offset]
+	joined := make([]byte, 0, len(existing)+len(data))
+	joined = append(joined, existing...)
+	chunks[offset] = append(joined, data...)
+}
+
+// shiftStartTime moves the start time stored in the metadata by offset.
+func shiftStartTime(record Record, offset time.Duration) {
+	startTime, err := time.Parse(time.RFC3339, record.Metadata()[MetadataStartTime])
+	if err != nil {
+		return
+	}
+	record.SetMetadata(MetadataStartTime, startTime.Add(offset).Format(time.RFC3339))
+}
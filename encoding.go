@@ -0,0 +1,129 @@
+package recmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// Encoding is the file format a record is stored in, independent of the RecordFormat of its chunks.
+type Encoding string
+
+const (
+	EncodingJSON   Encoding = "json"
+	EncodingNDJSON Encoding = "ndjson"
+	EncodingBinary Encoding = "binary"
+	EncodingYAML   Encoding = "yaml"
+	EncodingTTYRec Encoding = "ttyrec"
+)
+
+// Encodings lists all encodings records can be written in.
+var Encodings = []Encoding{EncodingJSON, EncodingNDJSON, EncodingBinary, EncodingYAML, EncodingTTYRec}
+
+// ParseEncoding parses the name of an encoding.
+func ParseEncoding(name string) (Encoding, error) {
+	for _, encoding := range Encodings {
+		if string(encoding) == name {
+			return encoding, nil
+		}
+	}
+	return "", fmt.Errorf("unknown encoding: %s", name)
+}
+
+// Extension returns the file extension of the encoding including the dot.
+func (e Encoding) Extension() string {
+	switch e {
+	case EncodingNDJSON:
+		return ".ndjson"
+	case EncodingBinary:
+		return ".recmd"
+	case EncodingYAML:
+		return ".yaml"
+	case EncodingTTYRec:
+		return ".ttyrec"
+	default:
+		return ".json"
+	}
+}
+
+// StoresMarkers reports whether records in the encoding keep their markers, ttyrec files only have output frames.
+func (e Encoding) StoresMarkers() bool {
+	return e != EncodingTTYRec
+}
+
+// detectSize is the number of bytes looked at to detect the encoding of a record.
+const detectSize = 512
+
+// EncodingFromPath returns the encoding matching the extension of path, ignoring compression and encryption extensions.
+// Unknown extensions result in EncodingJSON.
+func EncodingFromPath(path string) Encoding {
+	path = strings.TrimSuffix(TrimEncryptedExtension(path), CompressionFromPath(path).Extension())
+	if strings.HasSuffix(path, ".yml") {
+		return EncodingYAML
+	}
+	for _, encoding := range Encodings {
+		if strings.HasSuffix(path, encoding.Extension()) {
+			return encoding
+		}
+	}
+	return EncodingJSON
+}
+
+// DetectEncoding detects the encoding of the (decompressed) record in r without consuming it.
+func DetectEncoding(r *bufio.Reader) (Encoding, error) {
+	start, err := r.Peek(detectSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return "", err
+	}
+
+	if bytes.HasPrefix(start, binaryMagic) {
+		return EncodingBinary, nil
+	}
+
+	if looksLikeTTYRec(start) {
+		return EncodingTTYRec, nil
+	}
+
+	firstLine, _, _ := bytes.Cut(start, []byte("\n"))
+	if gjson.GetBytes(firstLine, "type").String() == string(EntryHeader) {
+		return EncodingNDJSON, nil
+	}
+
+	// json records are objects, everything else is taken as yaml
+	trimmed := bytes.TrimSpace(start)
+	if len(trimmed) > 0 && trimmed[0] != '{' {
+		return EncodingYAML, nil
+	}
+
+	return EncodingJSON, nil
+}
+
+// Encode writes the record to w in the given encoding.
+// Only json keeps extra streams, encoding a record with extra streams in another encoding fails.
+func Encode(w io.Writer, record Record, encoding Encoding) error {
+	if encoding != EncodingJSON && encoding != "" {
+		if err := extraStreamsError(record, string(encoding)+" records"); err != nil {
+			return err
+		}
+	}
+
+	switch encoding {
+	case EncodingJSON, "":
+		return json.NewEncoder(w).Encode(record)
+	case EncodingNDJSON:
+		return EncodeNDJSON(w, record)
+	case EncodingBinary:
+		return EncodeBinary(w, record)
+	case EncodingYAML:
+		return EncodeYAML(w, record)
+	case EncodingTTYRec:
+		return EncodeTTYRec(w, record)
+	default:
+		return fmt.Errorf("unknown encoding: %s", encoding)
+	}
+}
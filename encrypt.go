@@ -0,0 +1,362 @@
+package recmd
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Encrypted records start with a header followed by the (compressed) record in segments:
+//
+//	header:  magic "RECMDENC" | version | kdf | salt (16) | nonce prefix (16) | key check (16)
+//	segment: XChaCha20-Poly1305 of up to 64KiB of data
+//
+// The nonce of a segment is the nonce prefix followed by the segment counter, whose highest bit marks
+// the last segment, so reordered, dropped and truncated segments fail to authenticate.
+// Every segment authenticates the header as additional data. The key check is an HMAC of the header
+// keyed with the derived key and tells a wrong key apart from modified data.
+
+// EncryptedExtension is the extension added to the path of encrypted records.
+const EncryptedExtension = ".enc"
+
+const (
+	encryptVersion     = 1
+	encryptSegmentSize = 64 * 1024
+	encryptSaltSize    = 16
+	encryptPrefixSize  = 16
+	encryptCheckSize   = 16
+	encryptHeaderSize  = len("RECMDENC") + 2 + encryptSaltSize + encryptPrefixSize + encryptCheckSize
+	encryptLastSegment = uint64(1) << 63
+)
+
+var encryptMagic = []byte("RECMDENC")
+
+// kdf identifies how the encryption key is derived from the secret.
+type kdf byte
+
+const (
+	kdfPassphrase kdf = 1
+	kdfKeyFile    kdf = 2
+)
+
+func (k kdf) String() string {
+	switch k {
+	case kdfPassphrase:
+		return "passphrase"
+	case kdfKeyFile:
+		return "key file"
+	default:
+		return fmt.Sprintf("unknown kdf %d", byte(k))
+	}
+}
+
+var (
+	// ErrEncrypted is returned when loading an encrypted record without a key.
+	ErrEncrypted = errors.New("record is encrypted, a passphrase or key file is required")
+	// ErrWrongKey is returned when a record is decrypted with a key it was not encrypted with.
+	ErrWrongKey = errors.New("decrypt: wrong passphrase or key file")
+	// ErrTampered is returned when the encrypted data was modified or truncated.
+	ErrTampered = errors.New("decrypt: record was modified or truncated")
+)
+
+// Key is the secret records are encrypted with, either a passphrase or the content of a key file.
+type Key struct {
+	kdf    kdf
+	secret []byte
+}
+
+// PassphraseKey returns a key derived from the passphrase with scrypt.
+func PassphraseKey(passphrase string) *Key {
+	return &Key{kdf: kdfPassphrase, secret: []byte(passphrase)}
+}
+
+// KeyFileKey returns a key derived from the content of a key file with HKDF.
+// The content should be random, 32 bytes are enough.
+func KeyFileKey(content []byte) *Key {
+	return &Key{kdf: kdfKeyFile, secret: content}
+}
+
+// ReadKeyFile reads a key file, see KeyFileKey.
+func ReadKeyFile(path string) (*Key, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(content) < 16 {
+		return nil, fmt.Errorf("key file %s is too short, use at least 16 random bytes", path)
+	}
+	return KeyFileKey(content), nil
+}
+
+// derive derives the encryption key for the given salt.
+func (k *Key) derive(salt []byte) ([]byte, error) {
+	switch k.kdf {
+	case kdfPassphrase:
+		return scrypt.Key(k.secret, salt, 1<<15, 8, 1, chacha20poly1305.KeySize)
+	case kdfKeyFile:
+		key := make([]byte, chacha20poly1305.KeySize)
+		_, err := io.ReadFull(hkdf.New(sha256.New, k.secret, salt, []byte("recmd key file")), key)
+		return key, err
+	default:
+		return nil, fmt.Errorf("unknown kdf: %d", k.kdf)
+	}
+}
+
+// keyCheck returns the key check value of the header.
+func keyCheck(key []byte, header []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(header[:encryptHeaderSize-encryptCheckSize])
+	return mac.Sum(nil)[:encryptCheckSize]
+}
+
+// IsEncrypted reports whether the record in r is encrypted without consuming it.
+func IsEncrypted(r *bufio.Reader) (bool, error) {
+	magic, err := r.Peek(len(encryptMagic))
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	return bytes.Equal(magic, encryptMagic), nil
+}
+
+// TrimEncryptedExtension removes EncryptedExtension from path.
+func TrimEncryptedExtension(path string) string {
+	return strings.TrimSuffix(path, EncryptedExtension)
+}
+
+// NewEncryptWriter returns a writer encrypting into w with the key, which has to be closed to write the last segment.
+// The returned writer never closes w.
+func NewEncryptWriter(w io.Writer, key *Key) (io.WriteCloser, error) {
+	header := make([]byte, encryptHeaderSize)
+	copy(header, encryptMagic)
+	header[len(encryptMagic)] = encryptVersion
+	header[len(encryptMagic)+1] = byte(key.kdf)
+
+	random := header[len(encryptMagic)+2 : encryptHeaderSize-encryptCheckSize]
+	_, err := rand.Read(random)
+	if err != nil {
+		return nil, err
+	}
+	salt, prefix := random[:encryptSaltSize], random[encryptSaltSize:]
+
+	derived, err := key.derive(salt)
+	if err != nil {
+		return nil, err
+	}
+	copy(header[encryptHeaderSize-encryptCheckSize:], keyCheck(derived, header))
+
+	aead, err := chacha20poly1305.NewX(derived)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = w.Write(header)
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptWriter{
+		w:      w,
+		aead:   &segmentCipher{aead: aead, header: header, prefix: prefix},
+		buffer: make([]byte, 0, encryptSegmentSize),
+	}, nil
+}
+
+// segmentCipher seals and opens the numbered segments of an encrypted record.
+type segmentCipher struct {
+	aead    cipher.AEAD
+	header  []byte
+	prefix  []byte
+	counter uint64
+}
+
+func (c *segmentCipher) nonce(last bool) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSizeX)
+	copy(nonce, c.prefix)
+	counter := c.counter
+	if last {
+		counter |= encryptLastSegment
+	}
+	binary.BigEndian.PutUint64(nonce[encryptPrefixSize:], counter)
+	return nonce
+}
+
+func (c *segmentCipher) seal(plaintext []byte, last bool) []byte {
+	sealed := c.aead.Seal(nil, c.nonce(last), plaintext, c.header)
+	c.counter++
+	return sealed
+}
+
+func (c *segmentCipher) open(ciphertext []byte, last bool) ([]byte, error) {
+	opened, err := c.aead.Open(nil, c.nonce(last), ciphertext, c.header)
+	if err != nil {
+		return nil, ErrTampered
+	}
+	c.counter++
+	return opened, nil
+}
+
+type encryptWriter struct {
+	w      io.Writer
+	aead   *segmentCipher
+	buffer []byte
+	closed bool
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, fmt.Errorf("encrypt: write after close")
+	}
+
+	written := 0
+	for len(p) > 0 {
+		// a full buffer is only sealed once more data follows, it might be the last segment
+		if len(e.buffer) == encryptSegmentSize {
+			_, err := e.w.Write(e.aead.seal(e.buffer, false))
+			if err != nil {
+				return written, err
+			}
+			e.buffer = e.buffer[:0]
+		}
+		n := copy(e.buffer[len(e.buffer):encryptSegmentSize], p)
+		e.buffer = e.buffer[:len(e.buffer)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	_, err := e.w.Write(e.aead.seal(e.buffer, true))
+	return err
+}
+
+// NewDecryptReader returns a reader decrypting the encrypted record in r with the key.
+// It returns ErrWrongKey if the key does not match, reading returns ErrTampered for modified data.
+func NewDecryptReader(r io.Reader, key *Key) (io.Reader, error) {
+	header := make([]byte, encryptHeaderSize)
+	_, err := io.ReadFull(r, header)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, ErrTampered
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(header[:len(encryptMagic)], encryptMagic) {
+		return nil, fmt.Errorf("decrypt: not an encrypted record")
+	}
+	if version := header[len(encryptMagic)]; version != encryptVersion {
+		return nil, fmt.Errorf("decrypt: unsupported version: %d", version)
+	}
+	if used := kdf(header[len(encryptMagic)+1]); used != key.kdf {
+		return nil, fmt.Errorf("decrypt: record was encrypted with a %s, not a %s", used, key.kdf)
+	}
+
+	random := header[len(encryptMagic)+2 : encryptHeaderSize-encryptCheckSize]
+	salt, prefix := random[:encryptSaltSize], random[encryptSaltSize:]
+
+	derived, err := key.derive(salt)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(keyCheck(derived, header), header[encryptHeaderSize-encryptCheckSize:]) {
+		return nil, ErrWrongKey
+	}
+
+	aead, err := chacha20poly1305.NewX(derived)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decryptReader{
+		r:       bufio.NewReader(r),
+		aead:    &segmentCipher{aead: aead, header: header, prefix: prefix},
+		segment: make([]byte, encryptSegmentSize+aead.Overhead()),
+	}, nil
+}
+
+type decryptReader struct {
+	r       *bufio.Reader
+	aead    *segmentCipher
+	segment []byte
+	buffer  []byte
+	done    bool
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.buffer) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		err := d.next()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, d.buffer)
+	d.buffer = d.buffer[n:]
+	return n, nil
+}
+
+// next decrypts the next segment into the buffer.
+func (d *decryptReader) next() error {
+	n, err := io.ReadFull(d.r, d.segment)
+	last := false
+	switch err {
+	case nil:
+		_, err = d.r.Peek(1)
+		if err == io.EOF {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	case io.EOF, io.ErrUnexpectedEOF:
+		last = true
+	default:
+		return err
+	}
+
+	d.buffer, err = d.aead.open(d.segment[:n], last)
+	if err != nil {
+		return err
+	}
+	d.done = last
+	return nil
+}
+
+// Decrypt detects an encrypted record in r and returns a reader of the decrypted data.
+// Unencrypted data is passed through, encrypted data without a key results in ErrEncrypted.
+func Decrypt(r io.Reader, key *Key) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+
+	encrypted, err := IsEncrypted(buffered)
+	if err != nil {
+		return nil, err
+	}
+	if !encrypted {
+		return buffered, nil
+	}
+	if key == nil {
+		return nil, ErrEncrypted
+	}
+
+	return NewDecryptReader(buffered, key)
+}
@@ -3,9 +3,13 @@ module github.com/scaxyz/recmd
 go 1.19
 
 require (
+	github.com/klauspost/compress v1.17.0
 	github.com/samber/lo v1.38.1
 	github.com/tidwall/gjson v1.17.0
 	github.com/urfave/cli/v2 v2.25.7
+	golang.org/x/crypto v0.14.0
+	golang.org/x/image v0.18.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -15,4 +19,5 @@ require (
 	github.com/tidwall/pretty v1.2.1 // indirect
 	github.com/xrash/smetrics v0.0.0-20201216005158-039620a65673 // indirect
 	golang.org/x/exp v0.0.0-20231006140011-7918f672742d // indirect
+	golang.org/x/sys v0.13.0 // indirect
 )
@@ -0,0 +1,86 @@
+package recmd
+
+import (
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"html/template"
+	"io"
+	"strconv"
+)
+
+//go:embed html/player.html
+var htmlPlayer string
+
+var htmlPlayerTemplate = template.Must(template.New("player").Parse(htmlPlayer))
+
+// htmlEvent is an event as embedded in the player, the data is base64 encoded.
+type htmlEvent struct {
+	Time   float64 `json:"t"`
+	Stream Stream  `json:"s"`
+	Data   string  `json:"d"`
+}
+
+type htmlMarker struct {
+	Time  float64 `json:"t"`
+	Label string  `json:"label"`
+}
+
+type htmlRecord struct {
+	Command  string            `json:"command"`
+	ExitCode int               `json:"exitcode"`
+	Columns  int               `json:"columns"`
+	Lines    int               `json:"lines"`
+	Events   []htmlEvent       `json:"events"`
+	Markers  []htmlMarker      `json:"markers"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// EncodeHTML writes the record as a single HTML page with a terminal player, which needs no network access.
+// The page plays stdout, stderr and stdin with play/pause, seeking, speed control and stream toggles.
+// The title defaults to the command of the record.
+func EncodeHTML(w io.Writer, record Record, title string) error {
+	if title == "" {
+		title = record.Command()
+	}
+
+	embedded := htmlRecord{
+		Command:  record.Command(),
+		ExitCode: record.ExitCode(),
+		Columns:  80,
+		Lines:    24,
+		Events:   []htmlEvent{},
+		Markers:  []htmlMarker{},
+		Metadata: record.Metadata(),
+	}
+	if columns, err := strconv.Atoi(record.Metadata()[MetadataColumns]); err == nil && columns > 0 {
+		embedded.Columns = columns
+	}
+	if lines, err := strconv.Atoi(record.Metadata()[MetadataLines]); err == nil && lines > 0 {
+		embedded.Lines = lines
+	}
+	for _, event := range Events(record) {
+		embedded.Events = append(embedded.Events, htmlEvent{
+			Time:   event.Offset.Seconds(),
+			Stream: event.Stream,
+			Data:   base64.StdEncoding.EncodeToString(event.Data),
+		})
+	}
+	for _, marker := range record.Markers() {
+		embedded.Markers = append(embedded.Markers, htmlMarker{Time: marker.Offset.Seconds(), Label: marker.Label})
+	}
+
+	// json.Marshal escapes <, > and &, so the data can't end the script element
+	data, err := json.Marshal(embedded)
+	if err != nil {
+		return err
+	}
+
+	return htmlPlayerTemplate.Execute(w, struct {
+		Title  string
+		Record template.JS
+	}{
+		Title:  title,
+		Record: template.JS(data),
+	})
+}
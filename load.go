@@ -0,0 +1,124 @@
+package recmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tidwall/gjson"
+)
+
+// LoadOption configures how records are loaded.
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	key *Key
+}
+
+// WithKey decrypts encrypted records with the key, a nil key loads only unencrypted records.
+func WithKey(key *Key) LoadOption {
+	return func(o *loadOptions) {
+		o.key = key
+	}
+}
+
+// Open decrypts and decompresses the record in r and returns a reader of its encoded data.
+// If the returned reader is an io.Closer it should be closed after use.
+func Open(r io.Reader, options ...LoadOption) (io.Reader, error) {
+	opts := &loadOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+
+	decrypted, err := Decrypt(r, opts.key)
+	if err != nil {
+		return nil, err
+	}
+
+	return Decompress(decrypted)
+}
+
+// Load reads and decodes a record from r, detecting its encryption, compression and encoding.
+func Load(r io.Reader, options ...LoadOption) (Record, error) {
+	decompressed, err := Open(r, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	if closer, ok := decompressed.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	buffered := bufio.NewReader(decompressed)
+	encoding, err := DetectEncoding(buffered)
+	if err != nil {
+		return nil, err
+	}
+
+	switch encoding {
+	case EncodingNDJSON:
+		return DecodeNDJSON(buffered)
+	case EncodingYAML:
+		return DecodeYAML(buffered)
+	case EncodingTTYRec:
+		return DecodeTTYRec(buffered)
+	}
+
+	data, err := io.ReadAll(buffered)
+	if err != nil {
+		return nil, err
+	}
+
+	if encoding == EncodingBinary {
+		return DecodeBinary(bytes.NewReader(data))
+	}
+	return Decode(data)
+}
+
+// LoadFile reads and decodes the record stored at path.
+func LoadFile(path string, options ...LoadOption) (Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// uncompressed binary records are decoded block by block from the file instead of reading all of it first
+	if _, err := NewBinaryReader(file); err == nil {
+		return DecodeBinary(file)
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	return Load(file, options...)
+}
+
+// Decode decodes a json encoded record, choosing the record type by its format field.
+// Records without a format field are treated as base64 records.
+func Decode(data []byte) (Record, error) {
+	format := gjson.GetBytes(data, "format")
+
+	var record Record
+	switch RecordFormat(format.String()) {
+	case FormatBase64, "":
+		record = &ByteRecord{}
+	case FormatString:
+		record = &StringRecord{}
+	case FormatAuto:
+		record = &AutoRecord{}
+	default:
+		return nil, fmt.Errorf("unknown format: %s", format.String())
+	}
+
+	err := json.Unmarshal(data, record)
+	if err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
@@ -0,0 +1,273 @@
+package recmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MarkerSequence is the start of the OSC escape sequence a recorded command can write to
+// place a marker into the recording: "\x1b]6973;<label>\x07" (terminated by BEL or ESC \).
+const MarkerSequence = "\x1b]6973;"
+
+// Marker is a named position inside a record.
+type Marker struct {
+	Offset time.Duration `json:"offset"`
+	Label  string        `json:"label"`
+}
+
+// FindMarker returns the first marker of the record with the given label.
+func FindMarker(record Record, label string) (Marker, bool) {
+	for _, marker := range record.Markers() {
+		if marker.Label == label {
+			return marker, true
+		}
+	}
+	return Marker{}, false
+}
+
+// insertMarker adds the marker to the slice, keeping it sorted by offset.
+func insertMarker(markers []Marker, marker Marker) []Marker {
+	i := sort.Search(len(markers), func(i int) bool {
+		return markers[i].Offset > marker.Offset
+	})
+	markers = append(markers, Marker{})
+	copy(markers[i+1:], markers[i:])
+	markers[i] = marker
+	return markers
+}
+
+func cloneMarkers(markers []Marker) []Marker {
+	if markers == nil {
+		return nil
+	}
+	cloned := make([]Marker, len(markers))
+	copy(cloned, markers)
+	return cloned
+}
+
+// markerKeyReader strips the marker hotkey from the input and stores a marker for each press.
+type markerKeyReader struct {
+	input   io.Reader
+	key     byte
+	start   time.Time
+	mu      sync.Mutex
+	markers []Marker
+	// onMarker is called with every new marker if set.
+	onMarker func(Marker)
+}
+
+func (m *markerKeyReader) Read(p []byte) (int, error) {
+	for {
+		n, err := m.input.Read(p)
+		if n == 0 || bytes.IndexByte(p[:n], m.key) == -1 {
+			return n, err
+		}
+
+		kept := 0
+		for _, b := range p[:n] {
+			if b == m.key {
+				m.addMarker(time.Since(m.start))
+				continue
+			}
+			p[kept] = b
+			kept++
+		}
+
+		// Only the hotkey was read, don't hand an empty read to the command
+		if kept == 0 && err == nil {
+			continue
+		}
+		return kept, err
+	}
+}
+
+func (m *markerKeyReader) addMarker(offset time.Duration) {
+	m.mu.Lock()
+	marker := Marker{
+		Offset: offset,
+		Label:  fmt.Sprintf("mark-%d", len(m.markers)+1),
+	}
+	m.markers = insertMarker(m.markers, marker)
+	m.mu.Unlock()
+
+	if m.onMarker != nil {
+		m.onMarker(marker)
+	}
+}
+
+func (m *markerKeyReader) Markers() []Marker {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return cloneMarkers(m.markers)
+}
+
+// extractMarkers removes marker escape sequences from the chunks and returns the markers found.
+// Sequences spanning multiple chunks are found as well, the marker is placed at the offset of
+// the chunk which contains the start of the sequence.
+func extractMarkers(chunks map[time.Duration][]byte) (map[time.Duration][]byte, []Marker) {
+	offsets := sortedOffsets(chunks)
+
+	var joined []byte
+	owners := []int{}
+	for i, offset := range offsets {
+		joined = append(joined, chunks[offset]...)
+		for range chunks[offset] {
+			owners = append(owners, i)
+		}
+	}
+
+	if !bytes.Contains(joined, []byte(MarkerSequence)) {
+		return chunks, nil
+	}
+
+	var markers []Marker
+	drop := make([]bool, len(joined))
+	searchFrom := 0
+	for {
+		start := bytes.Index(joined[searchFrom:], []byte(MarkerSequence))
+		if start == -1 {
+			break
+		}
+		start += searchFrom
+
+		labelStart := start + len(MarkerSequence)
+		labelEnd, end := findSequenceEnd(joined, labelStart)
+		if labelEnd == -1 {
+			// unterminated sequence, leave it as it is
+			break
+		}
+
+		markers = insertMarker(markers, Marker{
+			Offset: offsets[owners[start]],
+			Label:  string(joined[labelStart:labelEnd]),
+		})
+		for i := start; i < end; i++ {
+			drop[i] = true
+		}
+		searchFrom = end
+	}
+
+	cleaned := make(map[time.Duration][]byte)
+	for i, b := range joined {
+		if drop[i] {
+			continue
+		}
+		offset := offsets[owners[i]]
+		cleaned[offset] = append(cleaned[offset], b)
+	}
+
+	return cleaned, markers
+}
+
+// markerScannerMaxPending is how many bytes of an unterminated marker sequence a markerScanner holds back
+// before passing them on as data.
+const markerScannerMaxPending = 4096
+
+// markerScanner removes marker escape sequences from the chunks of a stream while they are recorded.
+// Bytes which may start a sequence are held back until the sequence is complete or turns out to be none,
+// like extractMarkers the marker is placed at the offset of the chunk containing the start of the sequence.
+type markerScanner struct {
+	pending       []byte
+	pendingOffset time.Duration
+}
+
+// write passes on the parts of the chunk, and of bytes held back before, which are not part of a marker sequence,
+// and the markers completed by the chunk, in the order they appear in the stream.
+func (m *markerScanner) write(offset time.Duration, data []byte, emit func(Event), mark func(Marker)) {
+	held := len(m.pending)
+	buffer := append(m.pending, data...)
+	offsetAt := func(i int) time.Duration {
+		if i < held {
+			return m.pendingOffset
+		}
+		return offset
+	}
+	pass := func(from int, to int) {
+		// the range may span the held bytes and the new chunk, which have different offsets
+		for from < to {
+			end := to
+			if from < held && held < to {
+				end = held
+			}
+			emit(Event{Offset: offsetAt(from), Data: append([]byte(nil), buffer[from:end]...)})
+			from = end
+		}
+	}
+
+	position := 0
+	for {
+		start := bytes.Index(buffer[position:], []byte(MarkerSequence))
+		if start == -1 {
+			break
+		}
+		start += position
+		pass(position, start)
+
+		labelStart := start + len(MarkerSequence)
+		labelEnd, end := findSequenceEnd(buffer, labelStart)
+		if labelEnd == -1 {
+			if len(buffer)-start > markerScannerMaxPending {
+				// too long for a label, pass it on unchanged
+				pass(start, len(buffer))
+				m.pending = nil
+			} else {
+				m.pendingOffset, m.pending = offsetAt(start), append([]byte(nil), buffer[start:]...)
+			}
+			return
+		}
+
+		mark(Marker{Offset: offsetAt(start), Label: string(buffer[labelStart:labelEnd])})
+		position = end
+	}
+
+	// the end of the chunk may be the start of a sequence continued by the next chunk
+	keep := 0
+	for length := len(MarkerSequence) - 1; length > 0; length-- {
+		if len(buffer)-position >= length && bytes.HasSuffix(buffer, []byte(MarkerSequence[:length])) {
+			keep = length
+			break
+		}
+	}
+	pass(position, len(buffer)-keep)
+	if keep > 0 {
+		m.pendingOffset, m.pending = offsetAt(len(buffer)-keep), append([]byte(nil), buffer[len(buffer)-keep:]...)
+	} else {
+		m.pending = nil
+	}
+}
+
+// flush passes on the bytes held back at the end of the recording.
+func (m *markerScanner) flush(emit func(Event)) {
+	if len(m.pending) > 0 {
+		emit(Event{Offset: m.pendingOffset, Data: m.pending})
+		m.pending = nil
+	}
+}
+
+// findSequenceEnd returns the end of the sequence content and the index after the terminator.
+func findSequenceEnd(data []byte, from int) (int, int) {
+	for i := from; i < len(data); i++ {
+		switch {
+		case data[i] == '\a':
+			return i, i + 1
+		case data[i] == 0x1b && i+1 < len(data) && data[i+1] == '\\':
+			return i, i + 2
+		}
+	}
+	return -1, -1
+}
+
+func sortedOffsets[V any](chunks map[time.Duration]V) []time.Duration {
+	offsets := make([]time.Duration, 0, len(chunks))
+	for offset := range chunks {
+		offsets = append(offsets, offset)
+	}
+	sort.Slice(offsets, func(i, j int) bool {
+		return offsets[i] < offsets[j]
+	})
+	return offsets
+}
@@ -0,0 +1,261 @@
+package recmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// NDJSONVersion is the version written into the header of ndjson records.
+const NDJSONVersion = 1
+
+// NDJSONEntryType is the type of a line of an ndjson record.
+type NDJSONEntryType string
+
+const (
+	EntryHeader  NDJSONEntryType = "header"
+	EntryEvent   NDJSONEntryType = "event"
+	EntryMarker  NDJSONEntryType = "marker"
+	EntryTrailer NDJSONEntryType = "trailer"
+)
+
+// NDJSONEntry is a single line of an ndjson record.
+//
+// A record starts with a header holding command and metadata, followed by events and markers
+// and ends with a trailer holding the exit code and metadata only known at the end, like digests.
+// Events are not necessarily sorted by offset.
+type NDJSONEntry struct {
+	Type NDJSONEntryType `json:"type"`
+
+	// header
+	Version int    `json:"version,omitempty"`
+	Command string `json:"command,omitempty"`
+
+	// header and trailer
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// event and marker
+	Offset time.Duration `json:"offset,omitempty"`
+	Stream Stream        `json:"stream,omitempty"`
+	Data   []byte        `json:"data,omitempty"`
+	Label  string        `json:"label,omitempty"`
+
+	// trailer
+	ExitCode *int `json:"exitcode,omitempty"`
+}
+
+// NDJSONEncoder writes an ndjson record line by line, it is safe for concurrent use.
+type NDJSONEncoder struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	err     error
+}
+
+// NewNDJSONEncoder creates an encoder writing to w.
+func NewNDJSONEncoder(w io.Writer) *NDJSONEncoder {
+	return &NDJSONEncoder{encoder: json.NewEncoder(w)}
+}
+
+func (e *NDJSONEncoder) write(entry NDJSONEntry) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.err != nil {
+		return e.err
+	}
+	e.err = e.encoder.Encode(entry)
+	return e.err
+}
+
+// WriteHeader writes the header, it has to be the first line.
+func (e *NDJSONEncoder) WriteHeader(command string, metadata map[string]string) error {
+	return e.write(NDJSONEntry{Type: EntryHeader, Version: NDJSONVersion, Command: command, Metadata: metadata})
+}
+
+// WriteEvent writes a chunk of a stream.
+func (e *NDJSONEncoder) WriteEvent(event Event) error {
+	return e.write(NDJSONEntry{Type: EntryEvent, Offset: event.Offset, Stream: event.Stream, Data: event.Data})
+}
+
+// WriteMarker writes a marker.
+func (e *NDJSONEncoder) WriteMarker(marker Marker) error {
+	return e.write(NDJSONEntry{Type: EntryMarker, Offset: marker.Offset, Label: marker.Label})
+}
+
+// WriteTrailer writes the trailer, it has to be the last line.
+// The metadata is added to the metadata of the header when decoding.
+func (e *NDJSONEncoder) WriteTrailer(exitCode int, metadata map[string]string) error {
+	return e.write(NDJSONEntry{Type: EntryTrailer, ExitCode: &exitCode, Metadata: metadata})
+}
+
+// Err returns the first error which occurred while writing.
+func (e *NDJSONEncoder) Err() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.err
+}
+
+// EncodeNDJSON writes the whole record as ndjson to w.
+// Events are sorted by offset and every marker is written before the first event at or after it.
+func EncodeNDJSON(w io.Writer, record Record) error {
+	encoder := NewNDJSONEncoder(w)
+	encoder.WriteHeader(record.Command(), record.Metadata())
+
+	markers := record.Markers()
+	for _, event := range Events(record) {
+		for len(markers) > 0 && markers[0].Offset <= event.Offset {
+			encoder.WriteMarker(markers[0])
+			markers = markers[1:]
+		}
+		encoder.WriteEvent(event)
+	}
+	for _, marker := range markers {
+		encoder.WriteMarker(marker)
+	}
+
+	encoder.WriteTrailer(record.ExitCode(), nil)
+	return encoder.Err()
+}
+
+// NDJSONDecoder reads an ndjson record line by line.
+type NDJSONDecoder struct {
+	reader  *bufio.Reader
+	pending []byte
+	header  NDJSONEntry
+	done    bool
+	follow  bool
+}
+
+// followInterval is the time waited for new data when following a record.
+var followInterval = 100 * time.Millisecond
+
+// NewNDJSONDecoder creates a decoder reading from r and reads the header.
+func NewNDJSONDecoder(r io.Reader) (*NDJSONDecoder, error) {
+	decoder := &NDJSONDecoder{reader: bufio.NewReader(r)}
+
+	header, err := decoder.Next()
+	if err != nil {
+		return nil, err
+	}
+	if header.Type != EntryHeader {
+		return nil, fmt.Errorf("ndjson: expected header, got %s", header.Type)
+	}
+	if header.Version > NDJSONVersion {
+		return nil, fmt.Errorf("ndjson: unsupported version: %d", header.Version)
+	}
+	decoder.header = header
+
+	return decoder, nil
+}
+
+// Follow makes the decoder wait for more data at the end of the input until the trailer is read,
+// allowing to read records which are still being written.
+func (d *NDJSONDecoder) Follow() {
+	d.follow = true
+}
+
+// Command returns the command from the header.
+func (d *NDJSONDecoder) Command() string {
+	return d.header.Command
+}
+
+// Metadata returns the metadata from the header.
+func (d *NDJSONDecoder) Metadata() map[string]string {
+	return d.header.Metadata
+}
+
+// Next returns the next entry, io.EOF after the trailer.
+// A missing trailer results in io.ErrUnexpectedEOF unless the decoder follows the input.
+func (d *NDJSONDecoder) Next() (NDJSONEntry, error) {
+	if d.done {
+		return NDJSONEntry{}, io.EOF
+	}
+
+	for {
+		line, err := d.reader.ReadBytes('\n')
+		d.pending = append(d.pending, line...)
+
+		if err == io.EOF {
+			if d.follow {
+				time.Sleep(followInterval)
+				continue
+			}
+			if len(d.pending) == 0 {
+				return NDJSONEntry{}, io.ErrUnexpectedEOF
+			}
+		} else if err != nil {
+			return NDJSONEntry{}, err
+		}
+
+		if len(d.pending) == 0 || (len(d.pending) == 1 && d.pending[0] == '\n') {
+			d.pending = d.pending[:0]
+			continue
+		}
+
+		var entry NDJSONEntry
+		err = json.Unmarshal(d.pending, &entry)
+		d.pending = d.pending[:0]
+		if err != nil {
+			return NDJSONEntry{}, fmt.Errorf("ndjson: %w", err)
+		}
+
+		if entry.Type == EntryTrailer {
+			d.done = true
+		}
+
+		return entry, nil
+	}
+}
+
+// DecodeNDJSON reads a whole ndjson record into a ByteRecord.
+func DecodeNDJSON(r io.Reader) (Record, error) {
+	decoder, err := NewNDJSONDecoder(r)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &ByteRecord{
+		JsonFormat: FormatBase64,
+		Cmd:        decoder.Command(),
+		Out:        make(map[time.Duration][]byte),
+		In:         make(map[time.Duration][]byte),
+		Err:        make(map[time.Duration][]byte),
+		Meta:       decoder.Metadata(),
+	}
+	targets := map[Stream]map[time.Duration][]byte{
+		StreamOut: record.Out,
+		StreamIn:  record.In,
+		StreamErr: record.Err,
+	}
+
+	for {
+		entry, err := decoder.Next()
+		if err == io.EOF {
+			return record, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch entry.Type {
+		case EntryEvent:
+			target, ok := targets[entry.Stream]
+			if !ok {
+				return nil, fmt.Errorf("ndjson: unknown stream: %s", entry.Stream)
+			}
+			appendChunk(target, entry.Offset, entry.Data)
+		case EntryMarker:
+			record.AddMarker(Marker{Offset: entry.Offset, Label: entry.Label})
+		case EntryTrailer:
+			if entry.ExitCode != nil {
+				record.ExitC = *entry.ExitCode
+			}
+			for key, value := range entry.Metadata {
+				record.SetMetadata(key, value)
+			}
+		}
+	}
+}
@@ -12,22 +12,22 @@ type RecordReader struct {
 	index            int
 	readCount        int
 	ignoreTime       bool
+	timing           Timing
+	markers          []Marker
+	markerIndex      int
+	onMarker         func(Marker)
+	startAt          time.Duration
+	stopAt           time.Duration
+	hasStop          bool
 }
 
 func NewReader(record Record) io.Reader {
 
 	data := make(map[time.Duration][]byte)
 
-	pipes := []map[time.Duration][]byte{
-		record.StdOut(),
-		record.StdIn(),
-		record.StdErr(),
-	}
-
-	for _, pipe := range pipes {
-		for k, v := range pipe {
-			data[k] = v
-		}
+	// Chunks of different streams with the same offset are joined in stream order
+	for _, event := range Events(record) {
+		appendChunk(data, event.Offset, event.Data)
 	}
 
 	// Collect time durations
@@ -47,6 +47,7 @@ func NewReader(record Record) io.Reader {
 	return &RecordReader{
 		data:             data,
 		sortedTimePoints: timePoints,
+		markers:          cloneMarkers(record.Markers()),
 	}
 
 }
@@ -54,6 +55,8 @@ func NewReader(record Record) io.Reader {
 func (rr *RecordReader) Reset() {
 	rr.index = 0
 	rr.readCount = 0
+	rr.markerIndex = 0
+	rr.StartAt(rr.startAt)
 }
 
 // IgnoreTime sets the ignoreTime field of the RecordReader struct to true.
@@ -67,6 +70,35 @@ func (rr *RecordReader) RespectTime() {
 	rr.ignoreTime = false
 }
 
+// SetTiming sets the policy applied to the delays between the data.
+func (rr *RecordReader) SetTiming(timing Timing) {
+	rr.timing = timing
+}
+
+// StartAt skips all data and markers before the given offset.
+func (rr *RecordReader) StartAt(offset time.Duration) {
+	rr.startAt = offset
+	rr.readCount = 0
+	rr.index = sort.Search(len(rr.sortedTimePoints), func(i int) bool {
+		return rr.sortedTimePoints[i] >= offset
+	})
+	rr.markerIndex = sort.Search(len(rr.markers), func(i int) bool {
+		return rr.markers[i].Offset >= offset
+	})
+}
+
+// StopAt ends the reading before the first data at or after the given offset.
+func (rr *RecordReader) StopAt(offset time.Duration) {
+	rr.stopAt = offset
+	rr.hasStop = true
+}
+
+// OnMarker sets a function which is called with every marker once the reading passes it.
+// The function is called before the data following the marker is returned.
+func (rr *RecordReader) OnMarker(handler func(Marker)) {
+	rr.onMarker = handler
+}
+
 // Read reads data from the RecordReader into the provided byte slice.
 //
 // It returns the number of bytes read and an error if any.
@@ -79,12 +111,18 @@ func (rr *RecordReader) Read(p []byte) (n int, err error) {
 	// Get the current time point
 	timePoint := rr.sortedTimePoints[rr.index]
 
+	if rr.hasStop && timePoint >= rr.stopAt {
+		return 0, io.EOF
+	}
+
 	// Calculate the time difference between the current time point and the previous one
 	var diff time.Duration = 0
-	if rr.index != 0 {
+	if rr.index != 0 && rr.sortedTimePoints[rr.index-1] >= rr.startAt {
 		diff = timePoint - rr.sortedTimePoints[rr.index-1]
 	}
 
+	firstRead := rr.readCount == 0
+
 	// Get the data to read from the current time point and readCount
 	data := rr.data[timePoint][rr.readCount:]
 
@@ -103,7 +141,17 @@ func (rr *RecordReader) Read(p []byte) (n int, err error) {
 
 	// Check if its the first read for the time point and it has a diff and the ignoreTime field is not set
 	if (rr.readCount == 0 && diff != 0) && !rr.ignoreTime {
-		<-time.NewTimer(diff).C
+		<-time.NewTimer(rr.timing.Delay(diff)).C
+	}
+
+	// Notify about all markers passed before this time point
+	if firstRead {
+		for rr.markerIndex < len(rr.markers) && rr.markers[rr.markerIndex].Offset <= timePoint {
+			if rr.onMarker != nil {
+				rr.onMarker(rr.markers[rr.markerIndex])
+			}
+			rr.markerIndex++
+		}
 	}
 
 	// Return the number of bytes read and nil error
@@ -13,6 +13,8 @@ type RecordFormat string
 const (
 	FormatString RecordFormat = "string"
 	FormatBase64 RecordFormat = "base64"
+	// FormatAuto stores chunks as plain text when they are valid UTF-8 and as base64 otherwise.
+	FormatAuto RecordFormat = "auto"
 )
 
 type Record interface {
@@ -24,6 +26,10 @@ type Record interface {
 	Reader() io.Reader
 	ConvertTo(format RecordFormat) (Record, error)
 	ExitCode() int
+	Markers() []Marker
+	AddMarker(marker Marker)
+	Metadata() map[string]string
+	SetMetadata(key string, value string)
 }
 
 type ByteRecord struct {
@@ -34,6 +40,12 @@ type ByteRecord struct {
 	In    map[time.Duration][]byte `json:"in"`
 	Err   map[time.Duration][]byte `json:"err"`
 	ExitC int                      `json:"exitcode"`
+	Marks []Marker                 `json:"markers,omitempty"`
+	Meta  map[string]string        `json:"metadata,omitempty"`
+
+	// Extra holds streams besides out, in and err, like other file descriptors of an strace import.
+	// Edits keep them, but only base64 json records store them, converting to other formats or encodings fails.
+	Extra map[Stream]map[time.Duration][]byte `json:"streams,omitempty"`
 }
 
 type StringRecord struct {
@@ -44,6 +56,8 @@ type StringRecord struct {
 	In    map[time.Duration]string `json:"in"`
 	Err   map[time.Duration]string `json:"err"`
 	ExitC int                      `json:"exitcode"`
+	Marks []Marker                 `json:"markers,omitempty"`
+	Meta  map[string]string        `json:"metadata,omitempty"`
 }
 
 // Reader returns a RecordReader object.
@@ -66,6 +80,29 @@ func (br *ByteRecord) StdErr() map[time.Duration][]byte {
 	return br.Err
 }
 
+// ExtraStreams returns the streams besides out, in and err, may be nil.
+func (br *ByteRecord) ExtraStreams() map[Stream]map[time.Duration][]byte {
+	return br.Extra
+}
+
+// chunks returns the chunks of the stream to add to, creating the map of an extra stream.
+func (br *ByteRecord) chunks(stream Stream) map[time.Duration][]byte {
+	targets := map[Stream]*map[time.Duration][]byte{StreamOut: &br.Out, StreamIn: &br.In, StreamErr: &br.Err}
+	if target, ok := targets[stream]; ok {
+		if *target == nil {
+			*target = make(map[time.Duration][]byte)
+		}
+		return *target
+	}
+	if br.Extra == nil {
+		br.Extra = make(map[Stream]map[time.Duration][]byte)
+	}
+	if br.Extra[stream] == nil {
+		br.Extra[stream] = make(map[time.Duration][]byte)
+	}
+	return br.Extra[stream]
+}
+
 func (br *ByteRecord) Command() string {
 	return br.Cmd
 }
@@ -74,6 +111,29 @@ func (br *ByteRecord) ExitCode() int {
 	return br.ExitC
 }
 
+// Markers returns the markers of the record sorted by offset.
+func (br *ByteRecord) Markers() []Marker {
+	return br.Marks
+}
+
+// AddMarker adds a marker to the record, keeping the markers sorted by offset.
+func (br *ByteRecord) AddMarker(marker Marker) {
+	br.Marks = insertMarker(br.Marks, marker)
+}
+
+// Metadata returns the metadata of the record, may be nil.
+func (br *ByteRecord) Metadata() map[string]string {
+	return br.Meta
+}
+
+// SetMetadata sets a metadata entry of the record.
+func (br *ByteRecord) SetMetadata(key string, value string) {
+	if br.Meta == nil {
+		br.Meta = make(map[string]string)
+	}
+	br.Meta[key] = value
+}
+
 func (br *ByteRecord) Format() RecordFormat {
 	if br.JsonFormat == "" {
 		br.JsonFormat = FormatBase64
@@ -82,35 +142,7 @@ func (br *ByteRecord) Format() RecordFormat {
 }
 
 func (br *ByteRecord) ConvertTo(format RecordFormat) (Record, error) {
-	switch format {
-	case FormatString:
-		convert := func(byteMap map[time.Duration][]byte) map[time.Duration]string {
-			convertedMap := make(map[time.Duration]string)
-			for k, v := range byteMap {
-				convertedMap[k] = string(v)
-			}
-			return convertedMap
-		}
-		return &StringRecord{
-			Cmd:        br.Cmd,
-			Out:        convert(br.Out),
-			In:         convert(br.In),
-			Err:        convert(br.Err),
-			JsonFormat: FormatString,
-		}, nil
-	case FormatBase64:
-		return &ByteRecord{
-			Cmd:        br.Cmd,
-			Out:        cloneMap(br.Out),
-			In:         cloneMap(br.In),
-			Err:        cloneMap(br.Err),
-			JsonFormat: br.JsonFormat,
-			ExitC:      br.ExitC,
-		}, nil
-	default:
-		return nil, fmt.Errorf("unknown format: %s", format)
-	}
-
+	return convertRecord(br, format)
 }
 
 func (sr *StringRecord) Reader() io.Reader {
@@ -143,6 +175,29 @@ func (sr *StringRecord) ExitCode() int {
 	return sr.ExitC
 }
 
+// Markers returns the markers of the record sorted by offset.
+func (sr *StringRecord) Markers() []Marker {
+	return sr.Marks
+}
+
+// AddMarker adds a marker to the record, keeping the markers sorted by offset.
+func (sr *StringRecord) AddMarker(marker Marker) {
+	sr.Marks = insertMarker(sr.Marks, marker)
+}
+
+// Metadata returns the metadata of the record, may be nil.
+func (sr *StringRecord) Metadata() map[string]string {
+	return sr.Meta
+}
+
+// SetMetadata sets a metadata entry of the record.
+func (sr *StringRecord) SetMetadata(key string, value string) {
+	if sr.Meta == nil {
+		sr.Meta = make(map[string]string)
+	}
+	sr.Meta[key] = value
+}
+
 func (sr *StringRecord) Format() RecordFormat {
 	if sr.JsonFormat == "" {
 		sr.JsonFormat = FormatString
@@ -151,38 +206,81 @@ func (sr *StringRecord) Format() RecordFormat {
 }
 
 func (sr *StringRecord) ConvertTo(format RecordFormat) (Record, error) {
+	return convertRecord(sr, format)
+}
+
+// convertRecord copies the record into a new record of the given format.
+// Only base64 records keep extra streams, converting a record with extra streams to another format fails.
+func convertRecord(record Record, format RecordFormat) (Record, error) {
+	if format != FormatBase64 {
+		if err := extraStreamsError(record, string(format)+" records"); err != nil {
+			return nil, err
+		}
+	}
+
 	switch format {
+	case FormatBase64:
+		convert := func(data []byte) []byte {
+			return data
+		}
+		converted := &ByteRecord{
+			Cmd:        record.Command(),
+			Out:        convertChunks(record.StdOut(), convert),
+			In:         convertChunks(record.StdIn(), convert),
+			Err:        convertChunks(record.StdErr(), convert),
+			JsonFormat: FormatBase64,
+			ExitC:      record.ExitCode(),
+			Marks:      cloneMarkers(record.Markers()),
+			Meta:       cloneMetadata(record.Metadata()),
+		}
+		for _, stream := range ExtraStreams(record) {
+			if converted.Extra == nil {
+				converted.Extra = make(map[Stream]map[time.Duration][]byte)
+			}
+			converted.Extra[stream] = convertChunks(StreamData(record, stream), convert)
+		}
+		return converted, nil
 	case FormatString:
+		convert := func(data []byte) string {
+			return string(data)
+		}
 		return &StringRecord{
-			Cmd:        sr.Cmd,
-			Out:        cloneMap(sr.Out),
-			In:         cloneMap(sr.In),
-			Err:        cloneMap(sr.Err),
-			JsonFormat: sr.JsonFormat,
-			ExitC:      sr.ExitC,
+			Cmd:        record.Command(),
+			Out:        convertChunks(record.StdOut(), convert),
+			In:         convertChunks(record.StdIn(), convert),
+			Err:        convertChunks(record.StdErr(), convert),
+			JsonFormat: FormatString,
+			ExitC:      record.ExitCode(),
+			Marks:      cloneMarkers(record.Markers()),
+			Meta:       cloneMetadata(record.Metadata()),
 		}, nil
-	case FormatBase64:
-		convert := func(stringMap map[time.Duration]string) map[time.Duration][]byte {
-			convertedMap := make(map[time.Duration][]byte)
-			for k, v := range stringMap {
-				convertedMap[k] = []byte(v)
-			}
-			return convertedMap
-
+	case FormatAuto:
+		convert := func(data []byte) AutoChunk {
+			return AutoChunk(data)
 		}
-		return &ByteRecord{
-			Cmd:        sr.Cmd,
-			Out:        convert(sr.Out),
-			In:         convert(sr.In),
-			Err:        convert(sr.Err),
-			JsonFormat: FormatBase64,
-			ExitC:      sr.ExitC,
+		return &AutoRecord{
+			Cmd:        record.Command(),
+			Out:        convertChunks(record.StdOut(), convert),
+			In:         convertChunks(record.StdIn(), convert),
+			Err:        convertChunks(record.StdErr(), convert),
+			JsonFormat: FormatAuto,
+			ExitC:      record.ExitCode(),
+			Marks:      cloneMarkers(record.Markers()),
+			Meta:       cloneMetadata(record.Metadata()),
 		}, nil
 	default:
 		return nil, fmt.Errorf("unknown format: %s", format)
 	}
 }
 
+func convertChunks[V any](chunks map[time.Duration][]byte, convert func([]byte) V) map[time.Duration]V {
+	converted := make(map[time.Duration]V)
+	for offset, data := range chunks {
+		converted[offset] = convert(data)
+	}
+	return converted
+}
+
 func cloneMap[K comparable, V any](originalMap map[K]V) map[K]V {
 	clonedMap := make(map[K]V)
 	for key, value := range originalMap {
@@ -190,3 +288,10 @@ func cloneMap[K comparable, V any](originalMap map[K]V) map[K]V {
 	}
 	return clonedMap
 }
+
+func cloneMetadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+	return cloneMap(metadata)
+}
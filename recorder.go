@@ -6,18 +6,95 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/scaxyz/recmd/timedpipe"
 )
 
-type Recorder struct{}
+// MetadataStartTime is the metadata key under which the recorder stores the start of the recording.
+const MetadataStartTime = "start_time"
+
+// MetadataColumns and MetadataLines are the metadata keys of the terminal size of a record, if it is known.
+const (
+	MetadataColumns = "columns"
+	MetadataLines   = "lines"
+)
+
+type Recorder struct {
+	markerKey      byte
+	useMarkerKey   bool
+	markerSequence bool
+	metadata       map[string]string
+	compact        *CompactOptions
+	stream         *NDJSONEncoder
+	redactor       *Redactor
+}
+
+type RecorderOption func(*Recorder)
+
+// WithMarkerKey enables placing markers by typing key into the recorded input.
+// The key itself is not passed on to the command.
+func WithMarkerKey(key byte) RecorderOption {
+	return func(r *Recorder) {
+		r.markerKey = key
+		r.useMarkerKey = true
+	}
+}
+
+// WithMarkerSequences enables placing markers by the command writing MarkerSequence to stdout or stderr.
+// The sequences are removed from the recorded output.
+func WithMarkerSequences() RecorderOption {
+	return func(r *Recorder) {
+		r.markerSequence = true
+	}
+}
+
+// WithMetadata stores the key value pair in the metadata of every record.
+func WithMetadata(key string, value string) RecorderOption {
+	return func(r *Recorder) {
+		if r.metadata == nil {
+			r.metadata = make(map[string]string)
+		}
+		r.metadata[key] = value
+	}
+}
+
+// WithCompaction compacts every record with the given options, see Compact.
+func WithCompaction(options CompactOptions) RecorderOption {
+	return func(r *Recorder) {
+		r.compact = &options
+	}
+}
+
+// WithNDJSONStream writes every record as ndjson to w while recording.
+// Markers are written when they are set, marker sequences are removed from the streamed chunks,
+// and compaction only applies to the returned record.
+func WithNDJSONStream(w io.Writer) RecorderOption {
+	return func(r *Recorder) {
+		r.stream = NewNDJSONEncoder(w)
+	}
+}
+
+// WithRedaction masks secrets in every record with the redactor before it is compacted and its digests are computed.
+// It can't be combined with WithNDJSONStream, which writes the data before it could be redacted.
+func WithRedaction(redactor *Redactor) RecorderOption {
+	return func(r *Recorder) {
+		r.redactor = redactor
+	}
+}
 
 // NewRecorder creates a new Recorder.
 //
-// It takes no parameters and returns a pointer to a Recorder.
-func NewRecorder() *Recorder {
-	return &Recorder{}
+// The options parameter is variadic and allows for configuration of the Recorder.
+// It returns a pointer to a Recorder.
+func NewRecorder(options ...RecorderOption) *Recorder {
+	recorder := &Recorder{}
+	for _, option := range options {
+		option(recorder)
+	}
+	return recorder
 }
 
 // Record records a command and returns a Record object with the command's output and error.
@@ -39,15 +116,45 @@ func (r *Recorder) Record(cmdStr string, input io.Reader, args ...string) (Recor
 	return record, nil
 }
 
-func (*Recorder) RecordCmd(cmd *exec.Cmd, input io.Reader) (Record, error) {
+func (r *Recorder) RecordCmd(cmd *exec.Cmd, input io.Reader) (Record, error) {
 
 	if cmd == nil {
 		return nil, fmt.Errorf("empty command")
 	}
 
-	errP := timedpipe.New(timedpipe.WithOutput(os.Stderr), timedpipe.StartNow())
-	outP := timedpipe.New(timedpipe.WithOutput(os.Stdout), timedpipe.StartNow())
-	inP := timedpipe.New(timedpipe.WithInput(input), timedpipe.StartNow())
+	if r.stream != nil && r.redactor != nil {
+		return nil, fmt.Errorf("redaction can't be combined with ndjson streaming")
+	}
+
+	start := time.Now()
+
+	var streamer *ndjsonStreamer
+	if r.stream != nil {
+		streamer = newNDJSONStreamer(r.stream, r.markerSequence)
+	}
+
+	var keyReader *markerKeyReader
+	if input != nil && r.useMarkerKey {
+		keyReader = &markerKeyReader{input: input, key: r.markerKey, start: start}
+		if streamer != nil {
+			keyReader.onMarker = streamer.marker
+		}
+		input = keyReader
+	}
+
+	errOptions := []timedpipe.PipeOption{timedpipe.WithOutput(os.Stderr), timedpipe.SetStartTime(start)}
+	outOptions := []timedpipe.PipeOption{timedpipe.WithOutput(os.Stdout), timedpipe.SetStartTime(start)}
+	inOptions := []timedpipe.PipeOption{timedpipe.WithInput(input), timedpipe.SetStartTime(start)}
+
+	if streamer != nil {
+		errOptions = append(errOptions, timedpipe.WithWriteHook(streamer.hook(StreamErr)))
+		outOptions = append(outOptions, timedpipe.WithWriteHook(streamer.hook(StreamOut)))
+		inOptions = append(inOptions, timedpipe.WithReadHook(streamer.hook(StreamIn)))
+	}
+
+	errP := timedpipe.New(errOptions...)
+	outP := timedpipe.New(outOptions...)
+	inP := timedpipe.New(inOptions...)
 
 	cmd.Stderr = errP
 	cmd.Stdout = outP
@@ -64,6 +171,15 @@ func (*Recorder) RecordCmd(cmd *exec.Cmd, input io.Reader) (Record, error) {
 		JsonFormat: FormatBase64,
 	}
 
+	record.SetMetadata(MetadataStartTime, start.Format(time.RFC3339))
+	for key, value := range r.metadata {
+		record.SetMetadata(key, value)
+	}
+
+	if r.stream != nil {
+		r.stream.WriteHeader(record.Command(), record.Metadata())
+	}
+
 	stopChan := make(chan os.Signal, 2)
 	signal.Notify(stopChan, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
 
@@ -85,6 +201,24 @@ func (*Recorder) RecordCmd(cmd *exec.Cmd, input io.Reader) (Record, error) {
 
 	record.ExitC = cmd.ProcessState.ExitCode()
 
+	if keyReader != nil {
+		for _, marker := range keyReader.Markers() {
+			record.AddMarker(marker)
+		}
+	}
+
+	if r.markerSequence {
+		var markers []Marker
+		record.Out, markers = extractMarkers(record.Out)
+		for _, marker := range markers {
+			record.AddMarker(marker)
+		}
+		record.Err, markers = extractMarkers(record.Err)
+		for _, marker := range markers {
+			record.AddMarker(marker)
+		}
+	}
+
 	if _, ok := err.(*exec.ExitError); ok {
 		err = nil
 	}
@@ -93,5 +227,107 @@ func (*Recorder) RecordCmd(cmd *exec.Cmd, input io.Reader) (Record, error) {
 		return nil, err
 	}
 
-	return record, nil
+	if streamer != nil {
+		err = streamer.finish(record)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var final Record = record
+	if r.redactor != nil {
+		final, _, err = r.redactor.Redact(final)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.compact != nil {
+		final, err = Compact(final, *r.compact)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	AddDigests(final)
+
+	return final, nil
+}
+
+// ndjsonStreamer writes a recording to an ndjson stream while it runs. Marker sequences are replaced by marker
+// entries, and what was written is kept for the digests of the trailer.
+type ndjsonStreamer struct {
+	encoder  *NDJSONEncoder
+	mu       sync.Mutex
+	scanners map[Stream]*markerScanner
+	written  *ByteRecord
+}
+
+func newNDJSONStreamer(encoder *NDJSONEncoder, markerSequence bool) *ndjsonStreamer {
+	s := &ndjsonStreamer{
+		encoder:  encoder,
+		scanners: make(map[Stream]*markerScanner),
+		written: &ByteRecord{
+			Out: make(map[time.Duration][]byte),
+			In:  make(map[time.Duration][]byte),
+			Err: make(map[time.Duration][]byte),
+		},
+	}
+	if markerSequence {
+		s.scanners[StreamOut] = &markerScanner{}
+		s.scanners[StreamErr] = &markerScanner{}
+	}
+	return s
+}
+
+func (s *ndjsonStreamer) hook(stream Stream) timedpipe.DataHook {
+	return func(offset time.Duration, data []byte) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		scanner, ok := s.scanners[stream]
+		if !ok {
+			s.event(Event{Offset: offset, Stream: stream, Data: append([]byte(nil), data...)})
+			return
+		}
+		scanner.write(offset, data, func(part Event) {
+			part.Stream = stream
+			s.event(part)
+		}, s.writeMarker)
+	}
+}
+
+// marker writes a marker set while recording.
+func (s *ndjsonStreamer) marker(marker Marker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writeMarker(marker)
+}
+
+func (s *ndjsonStreamer) event(event Event) {
+	s.encoder.WriteEvent(event)
+	appendChunk(StreamData(s.written, event.Stream), event.Offset, event.Data)
+}
+
+func (s *ndjsonStreamer) writeMarker(marker Marker) {
+	s.encoder.WriteMarker(marker)
+	s.written.AddMarker(marker)
+}
+
+// finish writes the bytes still held back for marker sequences and the trailer.
+func (s *ndjsonStreamer) finish(record *ByteRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, stream := range Streams {
+		if scanner, ok := s.scanners[stream]; ok {
+			scanner.flush(func(part Event) {
+				part.Stream = stream
+				s.event(part)
+			})
+		}
+	}
+
+	s.written.Cmd, s.written.ExitC, s.written.Meta = record.Cmd, record.ExitC, record.Metadata()
+	return s.encoder.WriteTrailer(record.ExitCode(), Digests(s.written))
 }
@@ -0,0 +1,283 @@
+package recmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MetadataRedacted is the metadata key listing the redaction rules which fired, like "aws-access-key=2,env:TOKEN=1".
+const MetadataRedacted = "redacted"
+
+// DefaultRedactMask replaces redacted secrets if the Redactor has no mask.
+const DefaultRedactMask = "[REDACTED]"
+
+// RedactRule masks every match of its pattern, or only the first group if the pattern has groups.
+type RedactRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// NewRedactRule compiles a rule, the name is recorded in the metadata when the rule fires.
+func NewRedactRule(name string, pattern string) (RedactRule, error) {
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return RedactRule{}, fmt.Errorf("rule %s: %w", name, err)
+	}
+	return RedactRule{Name: name, Pattern: compiled}, nil
+}
+
+// EnvRedactRule returns a rule masking the value of the environment variable, named "env:<name>".
+// Unset variables and values shorter than 4 bytes, which would mask too much, are errors.
+func EnvRedactRule(name string) (RedactRule, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return RedactRule{}, fmt.Errorf("environment variable %s is not set", name)
+	}
+	if len(value) < 4 {
+		return RedactRule{}, fmt.Errorf("value of environment variable %s is too short to redact", name)
+	}
+	return RedactRule{Name: "env:" + name, Pattern: regexp.MustCompile(regexp.QuoteMeta(value))}, nil
+}
+
+// TokenRedactRules match the shapes of common access tokens and keys.
+var TokenRedactRules = []RedactRule{
+	{Name: "aws-access-key-id", Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{Name: "aws-secret-access-key", Pattern: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`)},
+	{Name: "github-token", Pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`)},
+	{Name: "gitlab-token", Pattern: regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}`)},
+	{Name: "slack-token", Pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{Name: "google-api-key", Pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{Name: "stripe-key", Pattern: regexp.MustCompile(`\b[rs]k_(?:live|test)_[0-9A-Za-z]{20,}`)},
+	{Name: "jwt", Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{Name: "bearer-token", Pattern: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/-]{20,}=*)`)},
+	{Name: "private-key", Pattern: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{Name: "password-assignment", Pattern: regexp.MustCompile(`(?i)(?:password|passwd|secret|token)["']?\s*[:=]\s*["']?([^\s"']{6,})`)},
+}
+
+// RedactHit is a secret found by a rule. Hits in the command have an empty stream.
+type RedactHit struct {
+	Rule string `json:"rule"`
+	Match
+}
+
+// Redactor masks secrets in records.
+type Redactor struct {
+	Rules []RedactRule
+	// Mask replaces every secret, DefaultRedactMask if empty.
+	Mask string
+	// Review decides for every hit in order of offset whether it is masked, all hits are masked if nil.
+	Review func(hit RedactHit) bool
+}
+
+// secretRange is the position of a secret inside joined data.
+type secretRange struct {
+	start int
+	end   int
+	rule  string
+}
+
+// find returns the non overlapping secrets in data sorted by position, overlaps belong to the earlier secret.
+func (r *Redactor) find(data []byte) []secretRange {
+	var found []secretRange
+	for _, rule := range r.Rules {
+		for _, location := range rule.Pattern.FindAllSubmatchIndex(data, -1) {
+			start, end := location[0], location[1]
+			if len(location) > 2 && location[2] >= 0 {
+				start, end = location[2], location[3]
+			}
+			if start < end {
+				found = append(found, secretRange{start: start, end: end, rule: rule.Name})
+			}
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].start < found[j].start
+	})
+
+	var merged []secretRange
+	for _, secret := range found {
+		if len(merged) > 0 && secret.start < merged[len(merged)-1].end {
+			if secret.end > merged[len(merged)-1].end {
+				merged[len(merged)-1].end = secret.end
+			}
+			continue
+		}
+		merged = append(merged, secret)
+	}
+	return merged
+}
+
+func (r *Redactor) mask() []byte {
+	if r.Mask == "" {
+		return []byte(DefaultRedactMask)
+	}
+	return []byte(r.Mask)
+}
+
+// Redact returns a copy of the record with the secrets in its command and streams masked and the masked hits.
+// A secret spanning multiple chunks is replaced by the mask in the chunk it starts in and removed from the others,
+// so the offsets of all chunks stay the same.
+func (r *Redactor) Redact(record Record) (Record, []RedactHit, error) {
+	redacted := remap(record, func(offset time.Duration) (time.Duration, bool) {
+		return offset, true
+	})
+
+	var masked []RedactHit
+	review := func(hit RedactHit) bool {
+		if r.Review == nil || r.Review(hit) {
+			masked = append(masked, hit)
+			return true
+		}
+		return false
+	}
+
+	command := []byte(redacted.Cmd)
+	var commandSecrets []secretRange
+	for _, secret := range r.find(command) {
+		hit := RedactHit{Rule: secret.rule, Match: Match{
+			Position: secret.start,
+			Text:     string(command[secret.start:secret.end]),
+			Line:     redacted.Cmd,
+		}}
+		if review(hit) {
+			commandSecrets = append(commandSecrets, secret)
+		}
+	}
+	redacted.Cmd = string(maskData(command, []int{0}, commandSecrets, r.mask())[0])
+
+	type streamHit struct {
+		hit    RedactHit
+		secret secretRange
+	}
+	var hits []streamHit
+	joinedStreams := make(map[Stream][]byte)
+	startsOfStreams := make(map[Stream][]int)
+
+	for _, stream := range RecordStreams(redacted) {
+		chunks := StreamData(redacted, stream)
+		offsets := sortedOffsets(chunks)
+
+		var joined []byte
+		starts := make([]int, len(offsets))
+		for i, offset := range offsets {
+			starts[i] = len(joined)
+			joined = append(joined, chunks[offset]...)
+		}
+		joinedStreams[stream], startsOfStreams[stream] = joined, starts
+
+		for _, secret := range r.find(joined) {
+			chunk := sort.Search(len(starts), func(i int) bool {
+				return starts[i] > secret.start
+			}) - 1
+			hits = append(hits, streamHit{
+				hit: RedactHit{Rule: secret.rule, Match: Match{
+					Stream:   stream,
+					Offset:   offsets[chunk],
+					Position: secret.start,
+					Text:     string(joined[secret.start:secret.end]),
+					Line:     lineAround(joined, secret.start, secret.end),
+				}},
+				secret: secret,
+			})
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].hit.Offset < hits[j].hit.Offset
+	})
+
+	accepted := make(map[Stream][]secretRange)
+	for _, hit := range hits {
+		if review(hit.hit) {
+			accepted[hit.hit.Stream] = append(accepted[hit.hit.Stream], hit.secret)
+		}
+	}
+
+	for stream, secrets := range accepted {
+		sort.Slice(secrets, func(i, j int) bool {
+			return secrets[i].start < secrets[j].start
+		})
+		chunks := redacted.chunks(stream)
+		offsets := sortedOffsets(chunks)
+		for i, data := range maskData(joinedStreams[stream], startsOfStreams[stream], secrets, r.mask()) {
+			chunks[offsets[i]] = data
+		}
+	}
+
+	if len(masked) > 0 {
+		redacted.SetMetadata(MetadataRedacted, countRules(redacted.Metadata()[MetadataRedacted], masked))
+	}
+
+	converted, err := redacted.ConvertTo(record.Format())
+	if err != nil {
+		return nil, nil, err
+	}
+	return converted, masked, nil
+}
+
+// maskData splits joined into the chunks beginning at starts, replacing the secrets with the mask.
+// The mask is placed in the chunk containing the start of a secret.
+func maskData(joined []byte, starts []int, secrets []secretRange, mask []byte) [][]byte {
+	chunks := make([][]byte, len(starts))
+	next := 0
+	for i, start := range starts {
+		end := len(joined)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+
+		data := []byte{}
+		for position := start; position < end; {
+			for next < len(secrets) && secrets[next].end <= position {
+				next++
+			}
+			if next < len(secrets) && secrets[next].start <= position {
+				if position == secrets[next].start {
+					data = append(data, mask...)
+				}
+				position = secrets[next].end
+				continue
+			}
+			until := end
+			if next < len(secrets) && secrets[next].start < end {
+				until = secrets[next].start
+			}
+			data = append(data, joined[position:until]...)
+			position = until
+		}
+		chunks[i] = data
+	}
+	return chunks
+}
+
+// countRules adds the rules of the hits to a list like "jwt=2,env:TOKEN=1".
+func countRules(existing string, hits []RedactHit) string {
+	counts := make(map[string]int)
+	for _, entry := range strings.Split(existing, ",") {
+		name, count, found := strings.Cut(entry, "=")
+		if n, err := strconv.Atoi(count); found && err == nil {
+			counts[name] += n
+		}
+	}
+	for _, hit := range hits {
+		counts[hit.Rule]++
+	}
+
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	entries := make([]string, len(names))
+	for i, name := range names {
+		entries[i] = fmt.Sprintf("%s=%d", name, counts[name])
+	}
+	return strings.Join(entries, ",")
+}
@@ -0,0 +1,133 @@
+package screen
+
+import (
+	"image"
+
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// The GIF renderer draws the 7x13 bitmap font of X11, which only covers ASCII. Box drawing and block characters
+// are drawn as lines and rectangles, a few typographic characters are replaced by ASCII and all others by U+FFFD.
+
+var (
+	glyphFace   = basicfont.Face7x13
+	glyphWidth  = glyphFace.Advance
+	glyphHeight = glyphFace.Ascent + glyphFace.Descent
+)
+
+// asciiFallbacks replaces characters missing in the font by similar ASCII characters.
+var asciiFallbacks = map[rune]rune{
+	'‘': '\'', '’': '\'', '‚': ',', '“': '"', '”': '"', '„': '"', '–': '-', '—': '-', '−': '-',
+	'…': '.', '•': '*', '·': '.', '°': 'o', '×': 'x', '÷': '/', '«': '<', '»': '>', '→': '>', '←': '<',
+	'↑': '^', '↓': 'v', '✓': 'v', '✔': 'v', '✗': 'x', '✘': 'x', '\u00a0': ' ',
+}
+
+// boxLines are the arms of box drawing characters from the center of the cell: up, down, left and right.
+// Heavy, double and rounded variants are drawn like the light ones.
+var boxLines = map[rune][4]bool{
+	'─': {false, false, true, true}, '━': {false, false, true, true}, '═': {false, false, true, true},
+	'│': {true, true, false, false}, '┃': {true, true, false, false}, '║': {true, true, false, false},
+	'┌': {false, true, false, true}, '┏': {false, true, false, true}, '╔': {false, true, false, true}, '╭': {false, true, false, true},
+	'┐': {false, true, true, false}, '┓': {false, true, true, false}, '╗': {false, true, true, false}, '╮': {false, true, true, false},
+	'└': {true, false, false, true}, '┗': {true, false, false, true}, '╚': {true, false, false, true}, '╰': {true, false, false, true},
+	'┘': {true, false, true, false}, '┛': {true, false, true, false}, '╝': {true, false, true, false}, '╯': {true, false, true, false},
+	'├': {true, true, false, true}, '┣': {true, true, false, true}, '╠': {true, true, false, true},
+	'┤': {true, true, true, false}, '┫': {true, true, true, false}, '╣': {true, true, true, false},
+	'┬': {false, true, true, true}, '┳': {false, true, true, true}, '╦': {false, true, true, true},
+	'┴': {true, false, true, true}, '┻': {true, false, true, true}, '╩': {true, false, true, true},
+	'┼': {true, true, true, true}, '╋': {true, true, true, true}, '╬': {true, true, true, true},
+	'╴': {false, false, true, false}, '╵': {true, false, false, false}, '╶': {false, false, false, true}, '╷': {false, true, false, false},
+}
+
+// blockShades are block elements as the covered part of the cell in eighths: left, top, right, bottom, and a shade
+// in quarters for the partially filled ones.
+var blockShades = map[rune][5]int{
+	'█': {0, 0, 8, 8, 4}, '▀': {0, 0, 8, 4, 4}, '▄': {0, 4, 8, 8, 4}, '▌': {0, 0, 4, 8, 4}, '▐': {4, 0, 8, 8, 4},
+	'▁': {0, 7, 8, 8, 4}, '▂': {0, 6, 8, 8, 4}, '▃': {0, 5, 8, 8, 4}, '▅': {0, 3, 8, 8, 4}, '▆': {0, 2, 8, 8, 4}, '▇': {0, 1, 8, 8, 4},
+	'▏': {0, 0, 1, 8, 4}, '▎': {0, 0, 2, 8, 4}, '▍': {0, 0, 3, 8, 4}, '▋': {0, 0, 5, 8, 4}, '▊': {0, 0, 6, 8, 4}, '▉': {0, 0, 7, 8, 4},
+	'░': {0, 0, 8, 8, 1}, '▒': {0, 0, 8, 8, 2}, '▓': {0, 0, 8, 8, 3}, '■': {1, 2, 7, 6, 4}, '◆': {2, 3, 6, 5, 4},
+}
+
+// glyphPixels calls set for every foreground pixel of the rune in a cell of glyphWidth x glyphHeight pixels.
+func glyphPixels(r rune, bold bool, set func(x int, y int)) {
+	if arms, ok := boxLines[r]; ok {
+		boxPixels(arms, set)
+		return
+	}
+	if block, ok := blockShades[r]; ok {
+		blockPixels(block, set)
+		return
+	}
+	if fallback, ok := asciiFallbacks[r]; ok {
+		r = fallback
+	}
+
+	dot := fixed.P(0, glyphFace.Ascent)
+	bounds, mask, origin, _, ok := glyphFace.Glyph(dot, r)
+	if !ok {
+		bounds, mask, origin, _, _ = glyphFace.Glyph(dot, '�')
+	}
+	alpha := mask.(*image.Alpha)
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			if alpha.AlphaAt(origin.X+x, origin.Y+y).A == 0 {
+				continue
+			}
+			set(bounds.Min.X+x, bounds.Min.Y+y)
+			if bold && bounds.Min.X+x+1 < glyphWidth {
+				// bitmap fonts are emboldened by drawing them twice
+				set(bounds.Min.X+x+1, bounds.Min.Y+y)
+			}
+		}
+	}
+}
+
+func boxPixels(arms [4]bool, set func(x int, y int)) {
+	centerX, centerY := glyphWidth/2, glyphHeight/2
+	if arms[0] {
+		for y := 0; y <= centerY; y++ {
+			set(centerX, y)
+		}
+	}
+	if arms[1] {
+		for y := centerY; y < glyphHeight; y++ {
+			set(centerX, y)
+		}
+	}
+	if arms[2] {
+		for x := 0; x <= centerX; x++ {
+			set(x, centerY)
+		}
+	}
+	if arms[3] {
+		for x := centerX; x < glyphWidth; x++ {
+			set(x, centerY)
+		}
+	}
+}
+
+func blockPixels(block [5]int, set func(x int, y int)) {
+	left, top := block[0]*glyphWidth/8, block[1]*glyphHeight/8
+	right, bottom := block[2]*glyphWidth/8, block[3]*glyphHeight/8
+	for y := top; y < bottom; y++ {
+		for x := left; x < right; x++ {
+			// shades set every pixel, every second or every fourth one in a checkerboard
+			switch block[4] {
+			case 1:
+				if x%2 != 0 || y%2 != 0 {
+					continue
+				}
+			case 2:
+				if (x+y)%2 != 0 {
+					continue
+				}
+			case 3:
+				if x%2 == 0 && y%2 == 0 {
+					continue
+				}
+			}
+			set(x, y)
+		}
+	}
+}
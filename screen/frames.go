@@ -0,0 +1,78 @@
+package screen
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/scaxyz/recmd"
+)
+
+// DefaultColumns and DefaultRows are the size of the screen for records without size metadata.
+const (
+	DefaultColumns = 80
+	DefaultRows    = 24
+)
+
+// Size returns the terminal size stored in the metadata of the record, DefaultColumns and DefaultRows if it has none.
+func Size(record recmd.Record) (int, int) {
+	columns, rows := DefaultColumns, DefaultRows
+	if value, err := strconv.Atoi(record.Metadata()[recmd.MetadataColumns]); err == nil && value > 0 {
+		columns = value
+	}
+	if value, err := strconv.Atoi(record.Metadata()[recmd.MetadataLines]); err == nil && value > 0 {
+		rows = value
+	}
+	return columns, rows
+}
+
+// Frame is the screen after all output up to its offset.
+type Frame struct {
+	Offset time.Duration
+	Snapshot
+}
+
+// Frames plays the stdout and stderr of the record on a screen of the given size and returns a frame for every
+// offset changing the screen, starting with the empty screen at offset 0. The offsets follow the timing policy.
+func Frames(record recmd.Record, columns int, rows int, timing recmd.Timing) []Frame {
+	s := New(columns, rows)
+	frames := []Frame{{Snapshot: s.Snapshot()}}
+
+	for _, event := range timing.Retime(recmd.Events(record, recmd.StreamOut, recmd.StreamErr)) {
+		s.Write(event.Data)
+		snapshot := s.Snapshot()
+
+		last := &frames[len(frames)-1]
+		switch {
+		case last.Offset == event.Offset:
+			last.Snapshot = snapshot
+		case !last.Snapshot.Equal(snapshot):
+			frames = append(frames, Frame{Offset: event.Offset, Snapshot: snapshot})
+		}
+	}
+
+	// merging frames of equal offsets may leave identical neighbours
+	deduplicated := frames[:1]
+	for _, frame := range frames[1:] {
+		if !frame.Snapshot.Equal(deduplicated[len(deduplicated)-1].Snapshot) {
+			deduplicated = append(deduplicated, frame)
+		}
+	}
+	return deduplicated
+}
+
+// AtExit is the offset of At showing the screen after all output.
+const AtExit = time.Duration(math.MaxInt64)
+
+// At plays the stdout and stderr of the record up to and including the offset on a screen of the given size
+// and returns the screen.
+func At(record recmd.Record, columns int, rows int, offset time.Duration) Snapshot {
+	s := New(columns, rows)
+	for _, event := range recmd.Events(record, recmd.StreamOut, recmd.StreamErr) {
+		if event.Offset > offset {
+			break
+		}
+		s.Write(event.Data)
+	}
+	return s.Snapshot()
+}
@@ -0,0 +1,292 @@
+package screen
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"time"
+)
+
+// GIFOptions configures EncodeGIF.
+type GIFOptions struct {
+	Theme Theme
+	// Scale multiplies the size of every pixel of the font, 1 if zero.
+	Scale int
+	// FPS caps the frames per second, 50 (the most GIF delays allow) if zero or above.
+	FPS float64
+	// Loop restarts the animation after showing the last frame for Hold, otherwise the last frame stays.
+	Loop bool
+	Hold time.Duration
+}
+
+const (
+	gifPadding  = 8
+	gifMaxFPS   = 50
+	gifMinDelay = 2
+)
+
+// EncodeGIF renders the frames with a bitmap font into an animated GIF.
+// Frames closer than the frame rate allows are merged into the later one, identical frames are dropped and
+// only the part of a frame changed since the previous one is stored.
+// GIFs hold at most 256 colors, if the frames use more every color is replaced by the closest theme, 256 palette
+// or grayscale color.
+func EncodeGIF(w io.Writer, frames []Frame, options GIFOptions) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames to encode")
+	}
+	if options.Scale < 1 {
+		options.Scale = 1
+	}
+	if options.FPS <= 0 || options.FPS > gifMaxFPS {
+		options.FPS = gifMaxFPS
+	}
+	frames = capFrameRate(frames, time.Duration(float64(time.Second)/options.FPS))
+
+	renderer := newGIFRenderer(frames, &options.Theme, options.Scale)
+
+	animation := &gif.GIF{
+		Config:    image.Config{ColorModel: renderer.palette, Width: renderer.canvas.Rect.Dx(), Height: renderer.canvas.Rect.Dy()},
+		LoopCount: -1,
+	}
+	if options.Loop {
+		animation.LoopCount = 0
+	}
+
+	var previous *Snapshot
+	for i := range frames {
+		changed := renderer.draw(&frames[i].Snapshot, previous)
+		previous = &frames[i].Snapshot
+
+		// the canvas is drawn on for the next frames, the GIF keeps a copy of the changed part
+		part := image.NewPaletted(changed, renderer.palette)
+		for y := changed.Min.Y; y < changed.Max.Y; y++ {
+			copy(part.Pix[part.PixOffset(changed.Min.X, y):part.PixOffset(changed.Max.X, y)],
+				renderer.canvas.Pix[renderer.canvas.PixOffset(changed.Min.X, y):renderer.canvas.PixOffset(changed.Max.X, y)])
+		}
+
+		// delays are computed from rounded offsets, so rounding errors don't add up
+		delay := centiseconds(options.Hold)
+		if i+1 < len(frames) {
+			delay = centiseconds(frames[i+1].Offset) - centiseconds(frames[i].Offset)
+		}
+		if delay < gifMinDelay {
+			delay = gifMinDelay
+		}
+
+		animation.Image = append(animation.Image, part)
+		animation.Delay = append(animation.Delay, delay)
+		animation.Disposal = append(animation.Disposal, gif.DisposalNone)
+	}
+
+	return gif.EncodeAll(w, animation)
+}
+
+func centiseconds(duration time.Duration) int {
+	return int((duration + 5*time.Millisecond) / (10 * time.Millisecond))
+}
+
+// capFrameRate merges frames closer than the interval into the earlier one, showing the later screen,
+// and drops frames identical to their predecessor.
+func capFrameRate(frames []Frame, interval time.Duration) []Frame {
+	var capped []Frame
+	for _, frame := range frames {
+		if len(capped) > 0 {
+			last := &capped[len(capped)-1]
+			if frame.Offset-last.Offset < interval {
+				last.Snapshot = frame.Snapshot
+				continue
+			}
+			if frame.Snapshot.Equal(last.Snapshot) {
+				continue
+			}
+		}
+		capped = append(capped, frame)
+	}
+	for i := 1; i < len(capped); i++ {
+		if capped[i].Snapshot.Equal(capped[i-1].Snapshot) {
+			capped = append(capped[:i], capped[i+1:]...)
+			i--
+		}
+	}
+	return capped
+}
+
+type gifRenderer struct {
+	theme   *Theme
+	scale   int
+	palette color.Palette
+	indexes map[[3]uint8]uint8
+	canvas  *image.Paletted
+}
+
+func newGIFRenderer(frames []Frame, theme *Theme, scale int) *gifRenderer {
+	rows, columns := 0, 0
+	if len(frames) > 0 {
+		rows = len(frames[0].Cells)
+		if rows > 0 {
+			columns = len(frames[0].Cells[0])
+		}
+	}
+
+	r := &gifRenderer{theme: theme, scale: scale, indexes: make(map[[3]uint8]uint8)}
+	r.palette = r.buildPalette(frames)
+	r.canvas = image.NewPaletted(image.Rect(0, 0, columns*glyphWidth*scale+2*gifPadding, rows*glyphHeight*scale+2*gifPadding), r.palette)
+	background := r.index(theme.Background)
+	for i := range r.canvas.Pix {
+		r.canvas.Pix[i] = background
+	}
+	return r
+}
+
+// colors returns the foreground and background of a cell, dim text is blended into the background.
+func (r *gifRenderer) colors(attr Attr) ([3]uint8, [3]uint8) {
+	fg, bg := r.theme.Colors(attr)
+	if attr.Dim {
+		for i := range fg {
+			fg[i] = uint8((int(fg[i]) + int(bg[i])) / 2)
+		}
+	}
+	return fg, bg
+}
+
+// buildPalette returns the colors of all frames if they fit into a GIF,
+// otherwise the theme colors, the 256 color palette and the grayscale ramp.
+func (r *gifRenderer) buildPalette(frames []Frame) color.Palette {
+	used := [][3]uint8{r.theme.Background, r.theme.Foreground}
+	seen := map[[3]uint8]bool{r.theme.Background: true, r.theme.Foreground: true}
+	add := func(c [3]uint8) {
+		if !seen[c] {
+			seen[c] = true
+			used = append(used, c)
+		}
+	}
+
+	exact := true
+	for _, frame := range frames {
+		for _, line := range frame.Cells {
+			for _, cell := range line {
+				fg, bg := r.colors(cell.Attr)
+				add(fg)
+				add(bg)
+			}
+		}
+		if len(used) > 256 {
+			exact = false
+			break
+		}
+	}
+
+	if !exact {
+		used = [][3]uint8{r.theme.Background, r.theme.Foreground}
+		seen = map[[3]uint8]bool{r.theme.Background: true, r.theme.Foreground: true}
+		for i := 0; i < 256; i++ {
+			red, green, blue := PaletteColor(uint8(i)).RGB(&r.theme.ANSI)
+			add([3]uint8{red, green, blue})
+		}
+		if len(used) > 256 {
+			used = used[:256]
+		}
+	}
+
+	palette := make(color.Palette, len(used))
+	for i, c := range used {
+		palette[i] = color.RGBA{R: c[0], G: c[1], B: c[2], A: 0xff}
+		r.indexes[c] = uint8(i)
+	}
+	return palette
+}
+
+// index returns the palette index of the color or the closest one.
+func (r *gifRenderer) index(c [3]uint8) uint8 {
+	if i, ok := r.indexes[c]; ok {
+		return i
+	}
+	i := uint8(r.palette.Index(color.RGBA{R: c[0], G: c[1], B: c[2], A: 0xff}))
+	r.indexes[c] = i
+	return i
+}
+
+// draw draws the cells differing from the previous snapshot, or all of them without one,
+// and returns the changed rectangle of the canvas.
+func (r *gifRenderer) draw(snapshot *Snapshot, previous *Snapshot) image.Rectangle {
+	cellWidth, cellHeight := glyphWidth*r.scale, glyphHeight*r.scale
+	var changed image.Rectangle
+	for y, line := range snapshot.Cells {
+		for x, cell := range line {
+			if cell.Rune == Continuation {
+				// drawn with the wide character to its left
+				continue
+			}
+			width := 1
+			if x+1 < len(line) && line[x+1].Rune == Continuation {
+				width = 2
+			}
+
+			cursor := snapshot.CursorVisible && snapshot.CursorY == y && snapshot.CursorX >= x && snapshot.CursorX < x+width
+			if previous != nil {
+				previousCursor := previous.CursorVisible && previous.CursorY == y && previous.CursorX >= x && previous.CursorX < x+width
+				if previousCursor == cursor && sameCells(previous.Cells[y][x:x+width], line[x:x+width]) {
+					continue
+				}
+			}
+
+			bounds := image.Rect(0, 0, width*cellWidth, cellHeight).Add(image.Pt(gifPadding+x*cellWidth, gifPadding+y*cellHeight))
+			r.drawCell(cell, cursor, bounds)
+			changed = changed.Union(bounds)
+		}
+	}
+	if changed.Empty() {
+		// GIF frames can't be empty
+		changed = image.Rect(0, 0, 1, 1)
+	}
+	if previous == nil {
+		changed = r.canvas.Rect
+	}
+	return changed
+}
+
+func (r *gifRenderer) drawCell(cell Cell, cursor bool, bounds image.Rectangle) {
+	fg, bg := r.colors(cell.Attr)
+	if cursor {
+		fg, bg = bg, fg
+	}
+	foreground, background := r.index(fg), r.index(bg)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r.canvas.SetColorIndex(x, y, background)
+		}
+	}
+
+	// glyphs of wide characters are centered in their two cells
+	left := bounds.Min.X + (bounds.Dx()-glyphWidth*r.scale)/2
+	set := func(x int, y int) {
+		for dy := 0; dy < r.scale; dy++ {
+			for dx := 0; dx < r.scale; dx++ {
+				r.canvas.SetColorIndex(left+x*r.scale+dx, bounds.Min.Y+y*r.scale+dy, foreground)
+			}
+		}
+	}
+	if cell.Rune != ' ' {
+		glyphPixels(cell.Rune, cell.Attr.Bold, set)
+	}
+	if cell.Attr.Underline {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			for dy := 0; dy < r.scale; dy++ {
+				r.canvas.SetColorIndex(x, bounds.Min.Y+glyphFace.Ascent*r.scale+dy, foreground)
+			}
+		}
+	}
+}
+
+// sameCells reports whether both runs of cells are equal.
+func sameCells(a []Cell, b []Cell) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
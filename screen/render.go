@@ -0,0 +1,138 @@
+package screen
+
+import (
+	"html"
+	"strconv"
+	"strings"
+)
+
+// ANSI returns the characters of the screen with SGR sequences for their attributes, without trailing spaces and
+// empty lines at the bottom. Every line ends with the default attributes.
+func (s Snapshot) ANSI() string {
+	lines := make([]string, len(s.Cells))
+	for y, line := range s.Cells {
+		line = trimBlank(line)
+		var builder strings.Builder
+		attr := Attr{}
+		for _, cell := range line {
+			if cell.Attr != attr {
+				builder.WriteString(sgr(cell.Attr))
+				attr = cell.Attr
+			}
+			if cell.Rune != Continuation {
+				builder.WriteRune(cell.Rune)
+			}
+		}
+		if attr != (Attr{}) {
+			builder.WriteString("\x1b[0m")
+		}
+		lines[y] = builder.String()
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// HTML returns the screen as a pre element with inline styles in the colors of the theme, without empty lines
+// at the bottom.
+func (s Snapshot) HTML(theme *Theme) string {
+	lines := s.Cells
+	for len(lines) > 0 && len(trimBlank(lines[len(lines)-1])) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	var builder strings.Builder
+	builder.WriteString(`<pre style="margin:0;padding:1em;font-family:ui-monospace,Menlo,Consolas,monospace;line-height:1.2;color:` +
+		hexColor(theme.Foreground) + ";background:" + hexColor(theme.Background) + `">`)
+	for y, line := range lines {
+		if y > 0 {
+			builder.WriteString("\n")
+		}
+		line = trimBlank(line)
+		for start := 0; start < len(line); {
+			end := start + 1
+			for end < len(line) && line[end].Attr == line[start].Attr {
+				end++
+			}
+			var text strings.Builder
+			for _, cell := range line[start:end] {
+				if cell.Rune != Continuation {
+					text.WriteRune(cell.Rune)
+				}
+			}
+			if style := htmlStyle(line[start].Attr, theme); style != "" {
+				builder.WriteString(`<span style="` + style + `">` + html.EscapeString(text.String()) + "</span>")
+			} else {
+				builder.WriteString(html.EscapeString(text.String()))
+			}
+			start = end
+		}
+	}
+	builder.WriteString("</pre>\n")
+	return builder.String()
+}
+
+// trimBlank removes the trailing spaces with default attributes of a line.
+func trimBlank(line []Cell) []Cell {
+	for len(line) > 0 && line[len(line)-1] == (Cell{Rune: ' '}) {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+// sgr returns the sequence resetting the attributes and setting the given ones.
+func sgr(attr Attr) string {
+	params := []string{"0"}
+	for _, flag := range []struct {
+		param string
+		set   bool
+	}{{"1", attr.Bold}, {"2", attr.Dim}, {"3", attr.Italic}, {"4", attr.Underline}, {"7", attr.Inverse}} {
+		if flag.set {
+			params = append(params, flag.param)
+		}
+	}
+	if !attr.Fg.IsDefault() {
+		params = append(params, colorParams(attr.Fg, 30))
+	}
+	if !attr.Bg.IsDefault() {
+		params = append(params, colorParams(attr.Bg, 40))
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+// colorParams returns the SGR parameters of a color, base is 30 for the foreground and 40 for the background.
+func colorParams(color Color, base int) string {
+	if index, ok := color.Palette(); ok {
+		switch {
+		case index < 8:
+			return strconv.Itoa(base + int(index))
+		case index < 16:
+			return strconv.Itoa(base + 60 + int(index) - 8)
+		default:
+			return strconv.Itoa(base+8) + ";5;" + strconv.Itoa(int(index))
+		}
+	}
+	r, g, b := color.RGB(nil)
+	return strconv.Itoa(base+8) + ";2;" + strconv.Itoa(int(r)) + ";" + strconv.Itoa(int(g)) + ";" + strconv.Itoa(int(b))
+}
+
+func htmlStyle(attr Attr, theme *Theme) string {
+	var styles []string
+	fg, bg := theme.Colors(attr)
+	if fg != theme.Foreground {
+		styles = append(styles, "color:"+hexColor(fg))
+	}
+	if bg != theme.Background {
+		styles = append(styles, "background:"+hexColor(bg))
+	}
+	for _, style := range []struct {
+		css string
+		set bool
+	}{{"font-weight:bold", attr.Bold}, {"opacity:.5", attr.Dim}, {"font-style:italic", attr.Italic}, {"text-decoration:underline", attr.Underline}} {
+		if style.set {
+			styles = append(styles, style.css)
+		}
+	}
+	return strings.Join(styles, ";")
+}
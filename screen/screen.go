@@ -0,0 +1,805 @@
+// Package screen emulates a VT100/xterm terminal screen, turning the output of a record into the cells a
+// terminal would show.
+package screen
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Color is a terminal color: the default color, one of the 256 palette colors or an RGB color.
+type Color uint32
+
+const (
+	// DefaultColor is the default foreground or background color of the terminal.
+	DefaultColor Color = 0
+
+	colorPalette Color = 1 << 24
+	colorRGB     Color = 2 << 24
+	colorKind    Color = 0xff << 24
+)
+
+// PaletteColor returns the color with the index in the 256 color palette, the first 16 are the ANSI colors.
+func PaletteColor(index uint8) Color {
+	return colorPalette | Color(index)
+}
+
+// RGBColor returns a truecolor color.
+func RGBColor(r, g, b uint8) Color {
+	return colorRGB | Color(r)<<16 | Color(g)<<8 | Color(b)
+}
+
+// IsDefault reports whether the color is the default color.
+func (c Color) IsDefault() bool {
+	return c&colorKind == 0
+}
+
+// Palette returns the palette index of the color and whether it is a palette color.
+func (c Color) Palette() (uint8, bool) {
+	return uint8(c), c&colorKind == colorPalette
+}
+
+// RGB returns the components of the color, ansi holds the 16 ANSI colors of the theme.
+// The other palette colors are the xterm color cube and grayscale ramp, the default color is black.
+func (c Color) RGB(ansi *[16][3]uint8) (uint8, uint8, uint8) {
+	switch c & colorKind {
+	case colorRGB:
+		return uint8(c >> 16), uint8(c >> 8), uint8(c)
+	case colorPalette:
+		index := uint8(c)
+		switch {
+		case index < 16:
+			return ansi[index][0], ansi[index][1], ansi[index][2]
+		case index < 232:
+			steps := [6]uint8{0, 95, 135, 175, 215, 255}
+			index -= 16
+			return steps[index/36], steps[index/6%6], steps[index%6]
+		default:
+			gray := 8 + (index-232)*10
+			return gray, gray, gray
+		}
+	default:
+		return 0, 0, 0
+	}
+}
+
+// Attr are the graphic attributes of a cell set by SGR sequences.
+type Attr struct {
+	Fg        Color
+	Bg        Color
+	Bold      bool
+	Dim       bool
+	Italic    bool
+	Underline bool
+	Inverse   bool
+}
+
+// Cell is a single character on the screen. Wide characters like CJK ideographs take two cells, the second one
+// holds Continuation.
+type Cell struct {
+	Rune rune
+	Attr Attr
+}
+
+// Continuation is the rune of the cell covered by the right half of a wide character.
+const Continuation rune = 0
+
+// Snapshot is a copy of the visible screen.
+type Snapshot struct {
+	Cells         [][]Cell
+	CursorX       int
+	CursorY       int
+	CursorVisible bool
+}
+
+// Equal reports whether both snapshots show the same cells and cursor.
+func (s Snapshot) Equal(other Snapshot) bool {
+	if s.CursorX != other.CursorX || s.CursorY != other.CursorY || s.CursorVisible != other.CursorVisible || len(s.Cells) != len(other.Cells) {
+		return false
+	}
+	for y, line := range s.Cells {
+		if len(line) != len(other.Cells[y]) {
+			return false
+		}
+		for x, cell := range line {
+			if cell != other.Cells[y][x] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Text returns the characters of the screen, without trailing spaces and empty lines at the bottom.
+func (s Snapshot) Text() string {
+	lines := make([]string, len(s.Cells))
+	for y, line := range s.Cells {
+		var builder strings.Builder
+		for _, cell := range line {
+			if cell.Rune != Continuation {
+				builder.WriteRune(cell.Rune)
+			}
+		}
+		lines[y] = strings.TrimRight(builder.String(), " ")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+type parserState int
+
+const (
+	stateGround parserState = iota
+	stateEscape
+	stateCharset
+	stateEscapeIntermediate
+	stateCSI
+	stateString
+	stateStringEscape
+)
+
+type cursor struct {
+	x, y        int
+	attr        Attr
+	wrapPending bool
+}
+
+// Screen is a terminal screen of a fixed size, written to like a terminal.
+// It understands the usual cursor movement, erase, scroll region, insert and delete, SGR color and
+// alternate screen sequences of xterm and ignores the others. Wide characters take two cells.
+type Screen struct {
+	columns int
+	rows    int
+
+	main      [][]Cell
+	alternate [][]Cell
+	cells     [][]Cell
+
+	cursor
+	saved         cursor
+	scrollTop     int
+	scrollBottom  int
+	cursorHidden  bool
+	noAutowrap    bool
+	charsets      [2]bool
+	shifted       bool
+	charsetTarget int
+
+	// NewlineReturns moves the cursor to the first column on a line feed, like a terminal with the onlcr
+	// setting. Output recorded from pipes contains no carriage returns and needs it, New enables it.
+	NewlineReturns bool
+	// Title is the window title set by the last OSC 0 or 2 sequence.
+	Title string
+
+	state    parserState
+	sequence []byte
+	isOSC    bool
+	pending  []byte
+}
+
+// New returns an empty screen of the size with NewlineReturns enabled.
+func New(columns int, rows int) *Screen {
+	if columns < 1 {
+		columns = 1
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	s := &Screen{columns: columns, rows: rows, NewlineReturns: true}
+	s.reset()
+	return s
+}
+
+func (s *Screen) reset() {
+	s.cursor = cursor{}
+	s.saved = cursor{}
+	s.main = s.blankLines(s.rows)
+	s.alternate = nil
+	s.cells = s.main
+	s.scrollTop, s.scrollBottom = 0, s.rows-1
+	s.cursorHidden, s.noAutowrap = false, false
+	s.charsets, s.shifted = [2]bool{}, false
+	s.state = stateGround
+}
+
+// Size returns the columns and rows of the screen.
+func (s *Screen) Size() (int, int) {
+	return s.columns, s.rows
+}
+
+// Cell returns the cell at the column and row.
+func (s *Screen) Cell(x int, y int) Cell {
+	return s.cells[y][x]
+}
+
+// Cursor returns the position of the cursor and whether it is visible.
+func (s *Screen) Cursor() (int, int, bool) {
+	return s.x, s.y, !s.cursorHidden
+}
+
+// AlternateScreen reports whether the alternate screen of full screen programs is shown.
+func (s *Screen) AlternateScreen() bool {
+	return s.alternate != nil
+}
+
+// Snapshot returns a copy of the visible screen.
+func (s *Screen) Snapshot() Snapshot {
+	cells := make([][]Cell, s.rows)
+	for y, line := range s.cells {
+		cells[y] = append([]Cell(nil), line...)
+	}
+	return Snapshot{Cells: cells, CursorX: s.x, CursorY: s.y, CursorVisible: !s.cursorHidden}
+}
+
+// Write interprets the data like a terminal, sequences and UTF-8 characters may be split across writes.
+func (s *Screen) Write(data []byte) (int, error) {
+	for _, b := range data {
+		s.process(b)
+	}
+	return len(data), nil
+}
+
+func (s *Screen) process(b byte) {
+	switch s.state {
+	case stateGround:
+		s.ground(b)
+	case stateEscape:
+		s.escape(b)
+	case stateCharset:
+		s.charsets[s.charsetTarget] = b == '0'
+		s.state = stateGround
+	case stateEscapeIntermediate:
+		if b >= 0x30 {
+			s.state = stateGround
+		}
+	case stateCSI:
+		switch {
+		case b >= 0x40 && b <= 0x7e:
+			s.state = stateGround
+			s.csi(string(s.sequence), b)
+		case b == 0x1b:
+			s.state = stateEscape
+		case len(s.sequence) < 256:
+			s.sequence = append(s.sequence, b)
+		}
+	case stateString:
+		switch b {
+		case 0x07:
+			s.endString()
+		case 0x1b:
+			s.state = stateStringEscape
+		default:
+			if len(s.sequence) < 4096 {
+				s.sequence = append(s.sequence, b)
+			}
+		}
+	case stateStringEscape:
+		if b == '\\' {
+			s.endString()
+			return
+		}
+		s.state = stateEscape
+		s.escape(b)
+	}
+}
+
+func (s *Screen) ground(b byte) {
+	if len(s.pending) > 0 || b >= 0x80 {
+		s.pending = append(s.pending, b)
+		if !utf8.FullRune(s.pending) {
+			return
+		}
+		r, size := utf8.DecodeRune(s.pending)
+		rest := s.pending[size:]
+		s.pending = nil
+		s.put(r)
+		for _, b := range rest {
+			s.process(b)
+		}
+		return
+	}
+
+	switch b {
+	case 0x1b:
+		s.state = stateEscape
+	case '\r':
+		s.x, s.wrapPending = 0, false
+	case '\n', '\v', '\f':
+		s.lineFeed()
+		if s.NewlineReturns {
+			s.x = 0
+		}
+	case '\b':
+		if s.x > 0 {
+			s.x--
+		}
+		s.wrapPending = false
+	case '\t':
+		s.x = (s.x/8 + 1) * 8
+		if s.x >= s.columns {
+			s.x = s.columns - 1
+		}
+	case 0x0e:
+		s.shifted = true
+	case 0x0f:
+		s.shifted = false
+	default:
+		if b >= 0x20 && b < 0x7f {
+			s.put(rune(b))
+		}
+	}
+}
+
+// decGraphics maps the DEC special graphics character set to the line drawing characters.
+var decGraphics = map[rune]rune{
+	'`': '◆', 'a': '▒', 'f': '°', 'g': '±', 'j': '┘', 'k': '┐', 'l': '┌', 'm': '└', 'n': '┼',
+	'o': '⎺', 'p': '⎻', 'q': '─', 'r': '⎼', 's': '⎽', 't': '├', 'u': '┤', 'v': '┴', 'w': '┬',
+	'x': '│', 'y': '≤', 'z': '≥', '{': 'π', '|': '≠', '}': '£', '~': '·',
+}
+
+func (s *Screen) put(r rune) {
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		// combining and format characters don't take a cell
+		return
+	}
+	if s.charsets[boolIndex(s.shifted)] {
+		if mapped, ok := decGraphics[r]; ok {
+			r = mapped
+		}
+	}
+
+	width := 1
+	if isWide(r) && s.columns > 1 {
+		width = 2
+	}
+
+	if s.wrapPending {
+		s.x = 0
+		s.lineFeed()
+	}
+	if s.x+width > s.columns {
+		// a wide character doesn't fit into the last column and is written to the next line like xterm does
+		if s.noAutowrap {
+			s.x = s.columns - width
+		} else {
+			s.eraseCells(s.y, s.x, s.columns)
+			s.x = 0
+			s.lineFeed()
+		}
+	}
+
+	s.cells[s.y][s.x] = Cell{Rune: r, Attr: s.attr}
+	if width == 2 {
+		s.cells[s.y][s.x+1] = Cell{Rune: Continuation, Attr: s.attr}
+	}
+	s.repairWide(s.y, s.x, s.x+width)
+	if s.x+width < s.columns {
+		s.x += width
+	} else {
+		s.x = s.columns - 1
+		s.wrapPending = !s.noAutowrap
+	}
+}
+
+func boolIndex(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+func (s *Screen) escape(b byte) {
+	s.state = stateGround
+	switch b {
+	case '[':
+		s.state, s.sequence = stateCSI, s.sequence[:0]
+	case ']', 'P', 'X', '^', '_':
+		s.state, s.sequence, s.isOSC = stateString, s.sequence[:0], b == ']'
+	case '(', ')':
+		s.state, s.charsetTarget = stateCharset, boolIndex(b == ')')
+	case '*', '+', '-', '.', '/', '#', '%', ' ':
+		s.state = stateEscapeIntermediate
+	case '7':
+		s.saved = s.cursor
+	case '8':
+		s.cursor = s.saved
+		s.clampCursor()
+	case 'D':
+		s.lineFeed()
+	case 'E':
+		s.lineFeed()
+		s.x = 0
+	case 'M':
+		s.reverseIndex()
+	case 'c':
+		s.reset()
+	}
+}
+
+func (s *Screen) endString() {
+	s.state = stateGround
+	if !s.isOSC {
+		return
+	}
+	code, text, _ := strings.Cut(string(s.sequence), ";")
+	if code == "0" || code == "2" {
+		s.Title = text
+	}
+}
+
+func (s *Screen) blank() Cell {
+	return Cell{Rune: ' ', Attr: Attr{Bg: s.attr.Bg}}
+}
+
+func (s *Screen) blankLine() []Cell {
+	line := make([]Cell, s.columns)
+	blank := s.blank()
+	for i := range line {
+		line[i] = blank
+	}
+	return line
+}
+
+func (s *Screen) blankLines(count int) [][]Cell {
+	lines := make([][]Cell, count)
+	for i := range lines {
+		lines[i] = s.blankLine()
+	}
+	return lines
+}
+
+func (s *Screen) clampCursor() {
+	s.x = clamp(s.x, 0, s.columns-1)
+	s.y = clamp(s.y, 0, s.rows-1)
+	s.wrapPending = false
+}
+
+func clamp(value int, low int, high int) int {
+	if value < low {
+		return low
+	}
+	if value > high {
+		return high
+	}
+	return value
+}
+
+func (s *Screen) lineFeed() {
+	s.wrapPending = false
+	switch {
+	case s.y == s.scrollBottom:
+		s.scrollUp(1)
+	case s.y < s.rows-1:
+		s.y++
+	}
+}
+
+func (s *Screen) reverseIndex() {
+	s.wrapPending = false
+	switch {
+	case s.y == s.scrollTop:
+		s.scrollDown(1)
+	case s.y > 0:
+		s.y--
+	}
+}
+
+// scrollUp moves the lines of the scroll region up, adding blank lines at its bottom.
+func (s *Screen) scrollUp(count int) {
+	s.deleteLinesAt(s.scrollTop, count)
+}
+
+// scrollDown moves the lines of the scroll region down, adding blank lines at its top.
+func (s *Screen) scrollDown(count int) {
+	s.insertLinesAt(s.scrollTop, count)
+}
+
+func (s *Screen) deleteLinesAt(y int, count int) {
+	count = clamp(count, 0, s.scrollBottom-y+1)
+	region := s.cells[y : s.scrollBottom+1]
+	copy(region, region[count:])
+	for i := len(region) - count; i < len(region); i++ {
+		region[i] = s.blankLine()
+	}
+}
+
+func (s *Screen) insertLinesAt(y int, count int) {
+	count = clamp(count, 0, s.scrollBottom-y+1)
+	region := s.cells[y : s.scrollBottom+1]
+	copy(region[count:], region)
+	for i := 0; i < count; i++ {
+		region[i] = s.blankLine()
+	}
+}
+
+func (s *Screen) eraseCells(y int, from int, to int) {
+	from, to = clamp(from, 0, s.columns), clamp(to, 0, s.columns)
+	blank := s.blank()
+	for x := from; x < to; x++ {
+		s.cells[y][x] = blank
+	}
+	s.repairWide(y, from, to)
+}
+
+func (s *Screen) csi(sequence string, final byte) {
+	private := ""
+	if sequence != "" && strings.ContainsRune("?<=>", rune(sequence[0])) {
+		private, sequence = sequence[:1], sequence[1:]
+	}
+	if strings.IndexFunc(sequence, func(r rune) bool { return r >= 0x20 && r <= 0x2f }) >= 0 {
+		// sequences with intermediate bytes like cursor styles don't change the screen
+		return
+	}
+
+	params := parseParams(sequence)
+	param := func(i int, fallback int) int {
+		if i < len(params) && params[i][0] > 0 {
+			return params[i][0]
+		}
+		return fallback
+	}
+
+	if private != "" && final != 'h' && final != 'l' {
+		return
+	}
+
+	switch final {
+	case 'A':
+		s.y = clamp(s.y-param(0, 1), s.scrollTopFor(), s.rows-1)
+		s.wrapPending = false
+	case 'B', 'e':
+		s.y = clamp(s.y+param(0, 1), 0, s.scrollBottomFor())
+		s.wrapPending = false
+	case 'C', 'a':
+		s.x = clamp(s.x+param(0, 1), 0, s.columns-1)
+		s.wrapPending = false
+	case 'D':
+		s.x = clamp(s.x-param(0, 1), 0, s.columns-1)
+		s.wrapPending = false
+	case 'E':
+		s.y, s.x = clamp(s.y+param(0, 1), 0, s.scrollBottomFor()), 0
+		s.wrapPending = false
+	case 'F':
+		s.y, s.x = clamp(s.y-param(0, 1), s.scrollTopFor(), s.rows-1), 0
+		s.wrapPending = false
+	case 'G', '`':
+		s.x = param(0, 1) - 1
+		s.clampCursor()
+	case 'd':
+		s.y = param(0, 1) - 1
+		s.clampCursor()
+	case 'H', 'f':
+		s.y, s.x = param(0, 1)-1, param(1, 1)-1
+		s.clampCursor()
+	case 'J':
+		switch param(0, 0) {
+		case 0:
+			s.eraseCells(s.y, s.x, s.columns)
+			for y := s.y + 1; y < s.rows; y++ {
+				s.cells[y] = s.blankLine()
+			}
+		case 1:
+			for y := 0; y < s.y; y++ {
+				s.cells[y] = s.blankLine()
+			}
+			s.eraseCells(s.y, 0, s.x+1)
+		case 2, 3:
+			for y := range s.cells {
+				s.cells[y] = s.blankLine()
+			}
+		}
+	case 'K':
+		switch param(0, 0) {
+		case 0:
+			s.eraseCells(s.y, s.x, s.columns)
+		case 1:
+			s.eraseCells(s.y, 0, s.x+1)
+		case 2:
+			s.eraseCells(s.y, 0, s.columns)
+		}
+	case 'X':
+		s.eraseCells(s.y, s.x, s.x+param(0, 1))
+	case 'P':
+		line := s.cells[s.y]
+		count := clamp(param(0, 1), 0, s.columns-s.x)
+		copy(line[s.x:], line[s.x+count:])
+		s.eraseCells(s.y, s.columns-count, s.columns)
+		s.repairWide(s.y, s.x, s.x)
+	case '@':
+		line := s.cells[s.y]
+		count := clamp(param(0, 1), 0, s.columns-s.x)
+		copy(line[s.x+count:], line[s.x:])
+		s.eraseCells(s.y, s.x, s.x+count)
+		s.repairWide(s.y, s.columns-1, s.columns)
+	case 'L':
+		if s.y >= s.scrollTop && s.y <= s.scrollBottom {
+			s.insertLinesAt(s.y, param(0, 1))
+			s.x = 0
+		}
+	case 'M':
+		if s.y >= s.scrollTop && s.y <= s.scrollBottom {
+			s.deleteLinesAt(s.y, param(0, 1))
+			s.x = 0
+		}
+	case 'S':
+		s.scrollUp(param(0, 1))
+	case 'T':
+		s.scrollDown(param(0, 1))
+	case 'r':
+		top, bottom := param(0, 1)-1, param(1, s.rows)-1
+		if top < bottom && bottom < s.rows {
+			s.scrollTop, s.scrollBottom = top, bottom
+			s.x, s.y, s.wrapPending = 0, 0, false
+		}
+	case 's':
+		s.saved = s.cursor
+	case 'u':
+		s.cursor = s.saved
+		s.clampCursor()
+	case 'm':
+		s.sgr(params)
+	case 'h', 'l':
+		if private == "?" {
+			for _, p := range params {
+				s.setMode(p[0], final == 'h')
+			}
+		}
+	}
+}
+
+// scrollTopFor returns the top row the cursor can move up to, the scroll region stops it if it is inside.
+func (s *Screen) scrollTopFor() int {
+	if s.y >= s.scrollTop {
+		return s.scrollTop
+	}
+	return 0
+}
+
+// scrollBottomFor returns the bottom row the cursor can move down to, the scroll region stops it if it is inside.
+func (s *Screen) scrollBottomFor() int {
+	if s.y <= s.scrollBottom {
+		return s.scrollBottom
+	}
+	return s.rows - 1
+}
+
+func (s *Screen) setMode(mode int, enabled bool) {
+	switch mode {
+	case 7:
+		s.noAutowrap = !enabled
+	case 25:
+		s.cursorHidden = !enabled
+	case 47, 1047, 1049:
+		if enabled == (s.alternate != nil) {
+			return
+		}
+		if enabled {
+			if mode == 1049 {
+				s.saved = s.cursor
+			}
+			s.alternate = s.blankLines(s.rows)
+			s.cells = s.alternate
+		} else {
+			s.alternate = nil
+			s.cells = s.main
+			if mode == 1049 {
+				s.cursor = s.saved
+				s.clampCursor()
+			}
+		}
+	}
+}
+
+// parseParams splits the parameters of a CSI sequence, each parameter with its colon separated sub parameters.
+// Missing values are 0.
+func parseParams(sequence string) [][]int {
+	if sequence == "" {
+		return nil
+	}
+	var params [][]int
+	for _, group := range strings.Split(sequence, ";") {
+		var values []int
+		for _, value := range strings.Split(group, ":") {
+			n, _ := strconv.Atoi(value)
+			values = append(values, n)
+		}
+		params = append(params, values)
+	}
+	return params
+}
+
+func (s *Screen) sgr(params [][]int) {
+	if len(params) == 0 {
+		params = [][]int{{0}}
+	}
+	for i := 0; i < len(params); i++ {
+		p := params[i]
+		switch code := p[0]; {
+		case code == 0:
+			s.attr = Attr{}
+		case code == 1:
+			s.attr.Bold = true
+		case code == 2:
+			s.attr.Dim = true
+		case code == 3:
+			s.attr.Italic = true
+		case code == 4:
+			s.attr.Underline = len(p) < 2 || p[1] != 0
+		case code == 7:
+			s.attr.Inverse = true
+		case code == 21:
+			s.attr.Underline = true
+		case code == 22:
+			s.attr.Bold, s.attr.Dim = false, false
+		case code == 23:
+			s.attr.Italic = false
+		case code == 24:
+			s.attr.Underline = false
+		case code == 27:
+			s.attr.Inverse = false
+		case code >= 30 && code <= 37:
+			s.attr.Fg = PaletteColor(uint8(code - 30))
+		case code >= 90 && code <= 97:
+			s.attr.Fg = PaletteColor(uint8(code - 90 + 8))
+		case code == 39:
+			s.attr.Fg = DefaultColor
+		case code >= 40 && code <= 47:
+			s.attr.Bg = PaletteColor(uint8(code - 40))
+		case code >= 100 && code <= 107:
+			s.attr.Bg = PaletteColor(uint8(code - 100 + 8))
+		case code == 49:
+			s.attr.Bg = DefaultColor
+		case code == 38 || code == 48:
+			var color Color
+			var ok bool
+			if len(p) > 1 {
+				color, ok = extendedColor(p[1:])
+			} else {
+				// the semicolon form takes the following parameters
+				var rest []int
+				for _, next := range params[i+1:] {
+					rest = append(rest, next[0])
+				}
+				var used int
+				color, used, ok = extendedColorParams(rest)
+				i += used
+			}
+			if ok && code == 38 {
+				s.attr.Fg = color
+			} else if ok {
+				s.attr.Bg = color
+			}
+		}
+	}
+}
+
+// extendedColor parses the colon form 5:<index> or 2:[<colorspace>:]<r>:<g>:<b>.
+func extendedColor(values []int) (Color, bool) {
+	switch {
+	case values[0] == 5 && len(values) >= 2:
+		return PaletteColor(uint8(values[1])), true
+	case values[0] == 2 && len(values) >= 5:
+		values = values[len(values)-3:]
+		return RGBColor(uint8(values[0]), uint8(values[1]), uint8(values[2])), true
+	case values[0] == 2 && len(values) == 4:
+		return RGBColor(uint8(values[1]), uint8(values[2]), uint8(values[3])), true
+	default:
+		return DefaultColor, false
+	}
+}
+
+// extendedColorParams parses the semicolon form 5;<index> or 2;<r>;<g>;<b>, returning the number of used parameters.
+func extendedColorParams(values []int) (Color, int, bool) {
+	switch {
+	case len(values) >= 2 && values[0] == 5:
+		return PaletteColor(uint8(values[1])), 2, true
+	case len(values) >= 4 && values[0] == 2:
+		return RGBColor(uint8(values[1]), uint8(values[2]), uint8(values[3])), 4, true
+	default:
+		return DefaultColor, len(values), false
+	}
+}
@@ -0,0 +1,202 @@
+package screen
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SVGOptions configures EncodeSVG.
+type SVGOptions struct {
+	Theme Theme
+	// FontSize is the font size in pixels, 14 if zero.
+	FontSize float64
+	// Window draws a window title bar with the Title.
+	Window bool
+	Title  string
+	// Loop restarts the animation after showing the last frame for Hold, otherwise the last frame stays.
+	Loop bool
+	Hold time.Duration
+}
+
+// EncodeSVG writes the frames as an SVG image animated with CSS keyframes, which needs no scripts.
+// Lines repeated in several frames are only written once.
+func EncodeSVG(w io.Writer, frames []Frame, options SVGOptions) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames to encode")
+	}
+	if options.FontSize <= 0 {
+		options.FontSize = 14
+	}
+
+	rows, columns := len(frames[0].Cells), 0
+	if rows > 0 {
+		columns = len(frames[0].Cells[0])
+	}
+
+	size := options.FontSize
+	charWidth, lineHeight := size*0.6, size*1.2
+	padding, chrome := size, 0.0
+	if options.Window {
+		chrome = size * 2.2
+	}
+	screenWidth, screenHeight := float64(columns)*charWidth, float64(rows)*lineHeight
+	width, height := screenWidth+2*padding, screenHeight+2*padding+chrome
+	theme := &options.Theme
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="%s" height="%s" viewBox="0 0 %s %s" font-family="ui-monospace,SFMono-Regular,Menlo,Consolas,'DejaVu Sans Mono',monospace" font-size="%s">`+"\n",
+		number(width), number(height), number(width), number(height), number(size))
+
+	total := frames[len(frames)-1].Offset
+	if options.Loop {
+		total += options.Hold
+	}
+	out.WriteString("<style>\n")
+	out.WriteString("text{white-space:pre}.b{font-weight:bold}.i{font-style:italic}.u{text-decoration:underline}.d{opacity:.5}\n")
+	if len(frames) > 1 && total > 0 {
+		iterations := "1 forwards"
+		if options.Loop {
+			iterations = "infinite"
+		}
+		fmt.Fprintf(out, ".a{animation:frames %ss steps(1,end) %s}\n", number(total.Seconds()), iterations)
+		out.WriteString("@keyframes frames{\n")
+		for i, frame := range frames {
+			fmt.Fprintf(out, "%s%%{transform:translateX(%spx)}\n", percent(frame.Offset, total), number(-float64(i)*screenWidth))
+		}
+		if frames[len(frames)-1].Offset < total {
+			fmt.Fprintf(out, "100%%{transform:translateX(%spx)}\n", number(-float64(len(frames)-1)*screenWidth))
+		}
+		out.WriteString("}\n")
+	}
+	out.WriteString("</style>\n")
+
+	fmt.Fprintf(out, `<rect width="%s" height="%s" rx="6" fill="%s"/>`+"\n", number(width), number(height), hexColor(theme.Background))
+	if options.Window {
+		radius := size * 0.4
+		for i, color := range []string{"#ff5f58", "#ffbd2e", "#18c132"} {
+			fmt.Fprintf(out, `<circle cx="%s" cy="%s" r="%s" fill="%s"/>`+"\n", number(padding+radius+float64(i)*radius*3.5), number(chrome/2+padding/2), number(radius), color)
+		}
+		fmt.Fprintf(out, `<text x="%s" y="%s" text-anchor="middle" fill="%s" opacity=".7">%s</text>`+"\n",
+			number(width/2), number(chrome/2+padding/2+size*0.35), hexColor(theme.Foreground), html.EscapeString(options.Title))
+	}
+
+	// lines are defined once and used by every frame showing them
+	lineIDs := make(map[string]string)
+	var definitions strings.Builder
+	frameLines := make([][]string, len(frames))
+	for i, frame := range frames {
+		frameLines[i] = make([]string, rows)
+		for y, line := range frame.Cells {
+			content := svgLine(line, theme, charWidth, lineHeight)
+			if content == "" {
+				continue
+			}
+			id, ok := lineIDs[content]
+			if !ok {
+				id = "l" + strconv.Itoa(len(lineIDs))
+				lineIDs[content] = id
+				fmt.Fprintf(&definitions, `<g id="%s">%s</g>`+"\n", id, content)
+			}
+			frameLines[i][y] = id
+		}
+	}
+	fmt.Fprintf(out, "<defs>\n%s</defs>\n", definitions.String())
+
+	fmt.Fprintf(out, `<svg x="%s" y="%s" width="%s" height="%s">`+"\n", number(padding), number(padding+chrome), number(screenWidth), number(screenHeight))
+	out.WriteString(`<g class="a">` + "\n")
+	for i, frame := range frames {
+		fmt.Fprintf(out, `<g transform="translate(%s)">`, number(float64(i)*screenWidth))
+		for y, id := range frameLines[i] {
+			if id != "" {
+				fmt.Fprintf(out, `<use xlink:href="#%s" y="%s"/>`, id, number(float64(y)*lineHeight))
+			}
+		}
+		if frame.CursorVisible {
+			fmt.Fprintf(out, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s" opacity=".6"/>`,
+				number(float64(frame.CursorX)*charWidth), number(float64(frame.CursorY)*lineHeight), number(charWidth), number(lineHeight), hexColor(theme.Foreground))
+		}
+		out.WriteString("</g>\n")
+	}
+	out.WriteString("</g>\n</svg>\n</svg>\n")
+
+	return out.Flush()
+}
+
+// svgLine renders the backgrounds and texts of a line at y 0, empty if it shows nothing.
+func svgLine(line []Cell, theme *Theme, charWidth float64, lineHeight float64) string {
+	var builder strings.Builder
+	var texts strings.Builder
+	for start := 0; start < len(line); {
+		end := start + 1
+		for end < len(line) && line[end].Attr == line[start].Attr {
+			end++
+		}
+		attr := line[start].Attr
+		fg, bg := theme.Colors(attr)
+
+		if bg != theme.Background {
+			fmt.Fprintf(&builder, `<rect x="%s" width="%s" height="%s" fill="%s"/>`,
+				number(float64(start)*charWidth), number(float64(end-start)*charWidth), number(lineHeight), hexColor(bg))
+		}
+
+		var classes []string
+		for _, class := range []struct {
+			name string
+			set  bool
+		}{{"b", attr.Bold}, {"i", attr.Italic}, {"u", attr.Underline}, {"d", attr.Dim}} {
+			if class.set {
+				classes = append(classes, class.name)
+			}
+		}
+		classAttribute := ""
+		if len(classes) > 0 {
+			classAttribute = ` class="` + strings.Join(classes, " ") + `"`
+		}
+
+		// wide characters get texts of their own, fonts rarely draw them exactly two cells wide
+		for from := start; from < end; {
+			to := from + 1
+			if !isWide(line[from].Rune) {
+				for to < end && !isWide(line[to].Rune) && line[to].Rune != Continuation {
+					to++
+				}
+			}
+			var run []rune
+			for _, cell := range line[from:to] {
+				run = append(run, cell.Rune)
+			}
+			text := strings.TrimRight(string(run), " ")
+			trimmed := strings.TrimLeft(text, " ")
+			if trimmed != "" {
+				column := from + len([]rune(text)) - len([]rune(trimmed))
+				fmt.Fprintf(&texts, `<text x="%s" y="%s" fill="%s"%s>%s</text>`,
+					number(float64(column)*charWidth), number(lineHeight*0.78), hexColor(fg), classAttribute, html.EscapeString(trimmed))
+			}
+			for to < end && line[to].Rune == Continuation {
+				to++
+			}
+			from = to
+		}
+		start = end
+	}
+	// texts are drawn above all backgrounds of the line
+	builder.WriteString(texts.String())
+	return builder.String()
+}
+
+// number formats a coordinate with at most two decimals.
+func number(value float64) string {
+	// adding 0 turns -0 into 0
+	return strconv.FormatFloat(math.Round(value*100)/100+0, 'f', -1, 64)
+}
+
+// percent returns the offset as percentage of the total with four decimals.
+func percent(offset time.Duration, total time.Duration) string {
+	return strconv.FormatFloat(float64(offset)/float64(total)*100, 'f', 4, 64)
+}
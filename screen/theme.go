@@ -0,0 +1,91 @@
+package screen
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Theme are the colors a screen is rendered with.
+type Theme struct {
+	Foreground [3]uint8
+	Background [3]uint8
+	// ANSI are the first 16 palette colors, the others are the same for all themes.
+	ANSI [16][3]uint8
+}
+
+// DefaultTheme is the name of the theme used if none is chosen.
+const DefaultTheme = "dark"
+
+// Themes are the built in themes by name.
+var Themes = map[string]Theme{
+	"dark": mustTheme("#d4d4d4", "#1e1e1e",
+		"#000000", "#cd3131", "#0dbc79", "#e5e510", "#2472c8", "#bc3fbc", "#11a8cd", "#e5e5e5",
+		"#666666", "#f14c4c", "#23d18b", "#f5f543", "#3b8eea", "#d670d6", "#29b8db", "#ffffff"),
+	"light": mustTheme("#333333", "#ffffff",
+		"#000000", "#cd3131", "#00bc00", "#949800", "#0451a5", "#bc05bc", "#0598bc", "#555555",
+		"#666666", "#cd3131", "#14ce14", "#b5ba00", "#0451a5", "#bc05bc", "#0598bc", "#a5a5a5"),
+	"solarized-dark": mustTheme("#839496", "#002b36",
+		"#073642", "#dc322f", "#859900", "#b58900", "#268bd2", "#d33682", "#2aa198", "#eee8d5",
+		"#002b36", "#cb4b16", "#586e75", "#657b83", "#839496", "#6c71c4", "#93a1a1", "#fdf6e3"),
+	"dracula": mustTheme("#f8f8f2", "#282a36",
+		"#21222c", "#ff5555", "#50fa7b", "#f1fa8c", "#bd93f9", "#ff79c6", "#8be9fd", "#f8f8f2",
+		"#6272a4", "#ff6e6e", "#69ff94", "#ffffa5", "#d6acff", "#ff92df", "#a4ffff", "#ffffff"),
+}
+
+// ThemeNames returns the names of the built in themes, sorted.
+func ThemeNames() []string {
+	names := make([]string, 0, len(Themes))
+	for name := range Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseTheme returns the built in theme with the name.
+func ParseTheme(name string) (Theme, error) {
+	theme, ok := Themes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme: %s, expected one of %s", name, strings.Join(ThemeNames(), ", "))
+	}
+	return theme, nil
+}
+
+// Colors returns the foreground and background components of the attributes, resolving default colors and inverse.
+func (t *Theme) Colors(attr Attr) ([3]uint8, [3]uint8) {
+	fg, bg := t.Foreground, t.Background
+	if !attr.Fg.IsDefault() {
+		r, g, b := attr.Fg.RGB(&t.ANSI)
+		fg = [3]uint8{r, g, b}
+	}
+	if !attr.Bg.IsDefault() {
+		r, g, b := attr.Bg.RGB(&t.ANSI)
+		bg = [3]uint8{r, g, b}
+	}
+	if attr.Inverse {
+		fg, bg = bg, fg
+	}
+	return fg, bg
+}
+
+func mustTheme(foreground string, background string, ansi ...string) Theme {
+	theme := Theme{Foreground: mustHex(foreground), Background: mustHex(background)}
+	for i, color := range ansi {
+		theme.ANSI[i] = mustHex(color)
+	}
+	return theme
+}
+
+func mustHex(color string) [3]uint8 {
+	value, err := strconv.ParseUint(strings.TrimPrefix(color, "#"), 16, 32)
+	if err != nil {
+		panic(err)
+	}
+	return [3]uint8{uint8(value >> 16), uint8(value >> 8), uint8(value)}
+}
+
+func hexColor(color [3]uint8) string {
+	return fmt.Sprintf("#%02x%02x%02x", color[0], color[1], color[2])
+}
@@ -0,0 +1,282 @@
+package recmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// util-linux script writes a timing file and log files with the raw data:
+//
+//	classic timing:   <delay> <bytes>                 output only
+//	advanced timing:  O|I <delay> <bytes>             output or input data
+//	                  S <delay> <signal> <info>       signals like SIGWINCH
+//	                  H <delay> <name> <value>        header values like START_TIME or EXIT_CODE
+//
+// Delays are the seconds since the previous entry. Every log file starts with a "Script started on" line
+// and ends with a "Script done on" line, which are not part of the timing.
+
+// ScriptFormat is the format of a script timing file.
+type ScriptFormat string
+
+const (
+	ScriptClassic  ScriptFormat = "classic"
+	ScriptAdvanced ScriptFormat = "advanced"
+)
+
+// ParseScriptFormat parses the name of a timing file format.
+func ParseScriptFormat(name string) (ScriptFormat, error) {
+	switch format := ScriptFormat(name); format {
+	case ScriptClassic, ScriptAdvanced:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown script timing format: %s, expected classic or advanced", name)
+	}
+}
+
+const (
+	scriptTimeLayout   = "2006-01-02 15:04:05-07:00"
+	scriptStartedLine  = "Script started on "
+	scriptMetadataName = "script."
+)
+
+var (
+	scriptCommandPattern  = regexp.MustCompile(`[[ ]COMMAND="((?:[^"\\]|\\.)*)"`)
+	scriptExitCodePattern = regexp.MustCompile(`COMMAND_EXIT_CODE="(\d+)"`)
+)
+
+// scriptLog is a log file of script, its header line is skipped on the first read.
+type scriptLog struct {
+	reader  *bufio.Reader
+	started bool
+	header  string
+}
+
+func newScriptLog(r io.Reader) *scriptLog {
+	return &scriptLog{reader: bufio.NewReader(r)}
+}
+
+func (l *scriptLog) start() error {
+	if l.started {
+		return nil
+	}
+	l.started = true
+
+	start, err := l.reader.Peek(len(scriptStartedLine))
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if string(start) != scriptStartedLine {
+		return nil
+	}
+	line, err := l.reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	l.header = strings.TrimSuffix(line, "\n")
+	return nil
+}
+
+func (l *scriptLog) read(size int) ([]byte, error) {
+	err := l.start()
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, size)
+	_, err = io.ReadFull(l.reader, data)
+	if err != nil {
+		return nil, fmt.Errorf("script: log ends before the timing file: %w", err)
+	}
+	return data, nil
+}
+
+// DecodeScript reads a session recorded by util-linux script from its timing file and log files.
+// input may be nil if input and output share a log file (script -B) or there is no input log.
+// Both the classic and the advanced timing format are detected.
+func DecodeScript(timing io.Reader, output io.Reader, input io.Reader) (Record, error) {
+	record := &ByteRecord{
+		JsonFormat: FormatBase64,
+		Out:        make(map[time.Duration][]byte),
+		In:         make(map[time.Duration][]byte),
+		Err:        make(map[time.Duration][]byte),
+	}
+
+	outputLog := newScriptLog(output)
+	inputLog := outputLog
+	if input != nil {
+		inputLog = newScriptLog(input)
+	}
+
+	scanner := bufio.NewScanner(timing)
+	var offset time.Duration
+	exitCodeFound := false
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		fields := strings.SplitN(line, " ", 4)
+		entryType := "O"
+		if line[0] >= '0' && line[0] <= '9' {
+			// classic entries have no type
+			fields = append([]string{entryType}, fields...)
+		} else {
+			entryType = fields[0]
+		}
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("script: timing line %d: expected at least 3 fields: %q", lineNumber, line)
+		}
+
+		delay, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || delay < 0 {
+			return nil, fmt.Errorf("script: timing line %d: invalid delay %q", lineNumber, fields[1])
+		}
+		offset += time.Duration(delay * float64(time.Second))
+
+		switch entryType {
+		case "O", "I":
+			size, err := strconv.Atoi(fields[2])
+			if err != nil || size < 0 {
+				return nil, fmt.Errorf("script: timing line %d: invalid size %q", lineNumber, fields[2])
+			}
+			log, target := outputLog, record.Out
+			if entryType == "I" {
+				log, target = inputLog, record.In
+			}
+			data, err := log.read(size)
+			if err != nil {
+				return nil, err
+			}
+			appendChunk(target, offset, data)
+		case "H":
+			value := strings.Join(fields[3:], " ")
+			switch fields[2] {
+			case "START_TIME":
+				if start, err := time.Parse(scriptTimeLayout, value); err == nil {
+					record.SetMetadata(MetadataStartTime, start.Format(time.RFC3339))
+				}
+			case "COMMAND":
+				record.Cmd = value
+			case "EXIT_CODE":
+				record.ExitC, _ = strconv.Atoi(value)
+				exitCodeFound = true
+			case "COLUMNS":
+				record.SetMetadata(MetadataColumns, value)
+			case "LINES":
+				record.SetMetadata(MetadataLines, value)
+			case "TIMING_LOG", "OUTPUT_LOG", "INPUT_LOG", "DURATION":
+				// paths and duration of the original capture
+			default:
+				record.SetMetadata(scriptMetadataName+strings.ToLower(fields[2]), value)
+			}
+		case "S":
+			// signals only move the time forward
+		default:
+			return nil, fmt.Errorf("script: timing line %d: unknown entry type %q", lineNumber, entryType)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// the header and footer lines of the log hold what a classic timing file lacks
+	err := outputLog.start()
+	if err != nil {
+		return nil, err
+	}
+	if record.Cmd == "" {
+		if match := scriptCommandPattern.FindStringSubmatch(outputLog.header); match != nil {
+			record.Cmd = match[1]
+		}
+	}
+	if _, ok := record.Metadata()[MetadataStartTime]; !ok {
+		at, _, _ := strings.Cut(strings.TrimPrefix(outputLog.header, scriptStartedLine), " [")
+		if start, err := time.Parse(scriptTimeLayout, at); err == nil {
+			record.SetMetadata(MetadataStartTime, start.Format(time.RFC3339))
+		}
+	}
+	if !exitCodeFound {
+		footer, err := io.ReadAll(outputLog.reader)
+		if err != nil {
+			return nil, err
+		}
+		if match := scriptExitCodePattern.FindSubmatch(footer); match != nil {
+			record.ExitC, _ = strconv.Atoi(string(match[1]))
+		}
+	}
+
+	return record, nil
+}
+
+// EncodeScript writes the record as timing file and log file of util-linux script, which scriptreplay plays.
+// The classic format only contains stdout and stderr, the advanced one also stdin, sharing the log file like script -B.
+func EncodeScript(timing io.Writer, log io.Writer, record Record, format ScriptFormat) error {
+	start := time.Now()
+	if startTime, err := time.Parse(time.RFC3339, record.Metadata()[MetadataStartTime]); err == nil {
+		start = startTime
+	}
+
+	timingWriter := bufio.NewWriter(timing)
+	logWriter := bufio.NewWriter(log)
+
+	fmt.Fprintf(logWriter, "%s%s [COMMAND=\"%s\"]\n", scriptStartedLine, start.Format(scriptTimeLayout), record.Command())
+
+	streams := []Stream{StreamOut, StreamErr}
+	if format == ScriptAdvanced {
+		streams = append(streams, StreamIn)
+		fmt.Fprintf(timingWriter, "H 0.000000 START_TIME %s\n", start.Format(scriptTimeLayout))
+		if record.Command() != "" {
+			fmt.Fprintf(timingWriter, "H 0.000000 COMMAND %s\n", record.Command())
+		}
+		for _, key := range []string{MetadataColumns, MetadataLines} {
+			if value, ok := record.Metadata()[key]; ok {
+				fmt.Fprintf(timingWriter, "H 0.000000 %s %s\n", strings.ToUpper(key), value)
+			}
+		}
+	}
+
+	// delays are computed from rounded offsets, so rounding errors don't add up
+	var previous int64
+	var duration time.Duration
+	for _, event := range Events(record, streams...) {
+		if len(event.Data) == 0 {
+			continue
+		}
+		micros := event.Offset.Microseconds()
+		delay := formatScriptDelay(micros - previous)
+		previous = micros
+		duration = event.Offset
+
+		switch {
+		case format != ScriptAdvanced:
+			fmt.Fprintf(timingWriter, "%s %d\n", delay, len(event.Data))
+		case event.Stream == StreamIn:
+			fmt.Fprintf(timingWriter, "I %s %d\n", delay, len(event.Data))
+		default:
+			fmt.Fprintf(timingWriter, "O %s %d\n", delay, len(event.Data))
+		}
+		logWriter.Write(event.Data)
+	}
+
+	if format == ScriptAdvanced {
+		fmt.Fprintf(timingWriter, "H 0.000000 DURATION %s\n", formatScriptDelay(duration.Microseconds()))
+		fmt.Fprintf(timingWriter, "H 0.000000 EXIT_CODE %d\n", record.ExitCode())
+	}
+
+	fmt.Fprintf(logWriter, "\nScript done on %s [COMMAND_EXIT_CODE=\"%d\"]\n", start.Add(duration).Format(scriptTimeLayout), record.ExitCode())
+
+	err := timingWriter.Flush()
+	if err != nil {
+		return err
+	}
+	return logWriter.Flush()
+}
+
+func formatScriptDelay(micros int64) string {
+	return fmt.Sprintf("%d.%06d", micros/1000000, micros%1000000)
+}
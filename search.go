@@ -0,0 +1,82 @@
+package recmd
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Match is a single match of a search inside a stream.
+type Match struct {
+	Stream Stream `json:"stream"`
+	// Offset is the offset of the chunk containing the start of the match.
+	Offset time.Duration `json:"offset"`
+	// Position is the byte position of the match inside the joined stream.
+	Position int    `json:"position"`
+	Text     string `json:"match"`
+	// Line is the line containing the match without escape sequences.
+	Line string `json:"line"`
+}
+
+// Search finds all matches of pattern in the given streams (all streams if none are given).
+// The chunks of a stream are joined before searching, so matches spanning multiple chunks are found.
+// Empty matches are skipped.
+func Search(record Record, pattern *regexp.Regexp, streams ...Stream) []Match {
+	if len(streams) == 0 {
+		streams = Streams
+	}
+
+	var matches []Match
+	for _, stream := range streams {
+		chunks := StreamData(record, stream)
+		offsets := sortedOffsets(chunks)
+		if len(offsets) == 0 {
+			continue
+		}
+
+		var joined []byte
+		starts := make([]int, len(offsets))
+		for i, offset := range offsets {
+			starts[i] = len(joined)
+			joined = append(joined, chunks[offset]...)
+		}
+
+		for _, location := range pattern.FindAllIndex(joined, -1) {
+			if location[0] == location[1] {
+				// patterns like "x*" also match the empty string between all bytes
+				continue
+			}
+			chunk := sort.Search(len(starts), func(i int) bool {
+				return starts[i] > location[0]
+			}) - 1
+
+			matches = append(matches, Match{
+				Stream:   stream,
+				Offset:   offsets[chunk],
+				Position: location[0],
+				Text:     string(joined[location[0]:location[1]]),
+				Line:     lineAround(joined, location[0], location[1]),
+			})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Offset < matches[j].Offset
+	})
+
+	return matches
+}
+
+func lineAround(data []byte, start int, end int) string {
+	lineStart := bytes.LastIndexByte(data[:start], '\n') + 1
+	lineEnd := len(data)
+	if i := bytes.IndexByte(data[end:], '\n'); i != -1 {
+		lineEnd = end + i
+	}
+	if lineEnd < end {
+		lineEnd = end
+	}
+	return strings.TrimRight(string(StripANSI(data[lineStart:lineEnd])), "\r")
+}
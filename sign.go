@@ -0,0 +1,93 @@
+package recmd
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SignatureExtension is the extension added to the path of a record for its detached signature.
+const SignatureExtension = ".sig"
+
+// ErrSignature is returned when a signature does not match the data and public key.
+var ErrSignature = errors.New("signature does not match")
+
+// ParsePrivateKey parses an Ed25519 private key in PKCS #8 PEM ("openssl genpkey -algorithm ed25519")
+// or OpenSSH format ("ssh-keygen -t ed25519").
+func ParsePrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	var key interface{}
+	var err error
+	if block, _ := pem.Decode(data); block != nil && block.Type == "PRIVATE KEY" {
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	} else {
+		key, err = ssh.ParseRawPrivateKey(data)
+	}
+	if _, ok := err.(*ssh.PassphraseMissingError); ok {
+		return nil, fmt.Errorf("private key is protected by a passphrase, which is not supported")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := key.(type) {
+	case ed25519.PrivateKey:
+		return key, nil
+	case *ed25519.PrivateKey:
+		return *key, nil
+	default:
+		return nil, fmt.Errorf("expected an ed25519 private key, got %T", key)
+	}
+}
+
+// ParsePublicKey parses an Ed25519 public key in PKIX PEM ("openssl pkey -pubout")
+// or OpenSSH authorized_keys format (the .pub file of ssh-keygen).
+func ParsePublicKey(data []byte) (ed25519.PublicKey, error) {
+	var key interface{}
+	if block, _ := pem.Decode(data); block != nil && block.Type == "PUBLIC KEY" {
+		var err error
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		sshKey, _, _, _, err := ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			return nil, err
+		}
+		cryptoKey, ok := sshKey.(ssh.CryptoPublicKey)
+		if !ok {
+			return nil, fmt.Errorf("unsupported public key type: %s", sshKey.Type())
+		}
+		key = cryptoKey.CryptoPublicKey()
+	}
+
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("expected an ed25519 public key, got %T", key)
+	}
+	return publicKey, nil
+}
+
+// Sign returns the detached signature of the data, base64 encoded on a single line.
+func Sign(data []byte, key ed25519.PrivateKey) []byte {
+	signature := ed25519.Sign(key, data)
+	return []byte(base64.StdEncoding.EncodeToString(signature) + "\n")
+}
+
+// VerifySignature checks a detached signature created by Sign, returning ErrSignature if it doesn't match.
+func VerifySignature(data []byte, signature []byte, key ed25519.PublicKey) error {
+	decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(signature)))
+	if err != nil || len(decoded) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid signature, expected %d base64 encoded bytes", ed25519.SignatureSize)
+	}
+	if !ed25519.Verify(key, data, decoded) {
+		return ErrSignature
+	}
+	return nil
+}
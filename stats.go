@@ -0,0 +1,70 @@
+package recmd
+
+import "time"
+
+// StreamStats summarizes the chunks of a single stream.
+type StreamStats struct {
+	Chunks     int           `json:"chunks"`
+	Bytes      int           `json:"bytes"`
+	LargestGap time.Duration `json:"largest_gap"`
+}
+
+// Stats summarizes a record.
+type Stats struct {
+	// Duration is the offset of the last chunk or marker.
+	Duration time.Duration `json:"duration"`
+	// FirstOutput is the offset of the first stdout or stderr chunk, -1 if there is no output.
+	FirstOutput time.Duration          `json:"time_to_first_output"`
+	Streams     map[Stream]StreamStats `json:"streams"`
+	// Throughput holds the bytes of all streams per equally sized time bucket.
+	Throughput []int `json:"throughput"`
+}
+
+// Statistics computes the stats of all streams of a record, including extra streams, splitting the throughput into the given number of buckets.
+func Statistics(record Record, buckets int) Stats {
+	stats := Stats{
+		Duration:    Duration(record),
+		FirstOutput: -1,
+		Streams:     make(map[Stream]StreamStats),
+	}
+
+	streams := RecordStreams(record)
+	events := Events(record, streams...)
+
+	last := make(map[Stream]time.Duration)
+	for _, event := range events {
+		streamStats := stats.Streams[event.Stream]
+		if previous, ok := last[event.Stream]; ok && event.Offset-previous > streamStats.LargestGap {
+			streamStats.LargestGap = event.Offset - previous
+		}
+		streamStats.Chunks++
+		streamStats.Bytes += len(event.Data)
+		stats.Streams[event.Stream] = streamStats
+		last[event.Stream] = event.Offset
+
+		if stats.FirstOutput == -1 && (event.Stream == StreamOut || event.Stream == StreamErr) {
+			stats.FirstOutput = event.Offset
+		}
+	}
+
+	for _, stream := range streams {
+		if _, ok := stats.Streams[stream]; !ok {
+			stats.Streams[stream] = StreamStats{}
+		}
+	}
+
+	if buckets <= 0 {
+		return stats
+	}
+
+	stats.Throughput = make([]int, buckets)
+	for _, event := range events {
+		bucket := 0
+		if stats.Duration > 0 {
+			bucket = int(int64(event.Offset) * int64(buckets) / int64(stats.Duration+1))
+		}
+		stats.Throughput[bucket] += len(event.Data)
+	}
+
+	return stats
+}
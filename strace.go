@@ -0,0 +1,517 @@
+package recmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// strace writes one line per system call, recorded with "strace -f -tt -e trace=read,write -o <file>":
+//
+//	1234  10:00:00.123456 write(1, "hello\n", 6) = 6
+//	[pid 1235] 10:00:00.123456 read(0, "x", 1) = 1
+//	1234  10:00:00.123456 read(0,  <unfinished ...>
+//	1234  10:00:00.223456 <... read resumed>"abc\n", 1024) = 4
+//	1234  10:00:00.323456 +++ exited with 0 +++
+//
+// The pid prefix is missing without -f, timestamps are also read as seconds since the epoch (-ttt).
+// Payloads longer than the string limit of strace (-s, 32 by default) end in "..." and are truncated.
+
+const (
+	// MetadataStraceTruncated is the metadata key counting the payloads strace truncated.
+	MetadataStraceTruncated = "strace.truncated"
+	// MetadataStracePID is the metadata key of the pid of the traced process.
+	MetadataStracePID = "strace.pid"
+	// MetadataStraceProcessPrefix starts the metadata keys of the other processes of an import, like
+	// "strace.process.1235.start", see StraceProcesses.
+	MetadataStraceProcessPrefix = "strace.process."
+
+	// straceMaxDayDeviation is how far a -tt time may go back before it is read as the next day.
+	straceMaxDayDeviation = 12 * time.Hour
+)
+
+var (
+	straceLinePattern    = regexp.MustCompile(`^(?:\[pid\s+(\d+)\]\s+|(\d+)\s+)?(\d{2}:\d{2}:\d{2}(?:\.\d+)?|\d+\.\d+)\s+(.*)$`)
+	straceCallPattern    = regexp.MustCompile(`^(\w+)\((.*)\)\s+=\s+(-?\d+|\?)`)
+	straceUnfinished     = regexp.MustCompile(`^(\w+)\((.*?)\s*<unfinished \.\.\.>$`)
+	straceResumed        = regexp.MustCompile(`^<\.\.\. (\w+) resumed>\s*(.*)$`)
+	straceExitedPattern  = regexp.MustCompile(`^\+\+\+ exited with (\d+) \+\+\+$`)
+	straceKilledPattern  = regexp.MustCompile(`^\+\+\+ killed by (SIG\w+)`)
+	straceFDPattern      = regexp.MustCompile(`^(\d+)(?:<[^>]*>)?,\s*`)
+	straceIOVBasePattern = regexp.MustCompile(`iov_base=`)
+	straceExecvePattern  = regexp.MustCompile(`^"(?:[^"\\]|\\.)*",\s*\[`)
+	straceSignalNumbers  = map[string]int{"SIGHUP": 1, "SIGINT": 2, "SIGQUIT": 3, "SIGILL": 4, "SIGTRAP": 5, "SIGABRT": 6, "SIGBUS": 7, "SIGFPE": 8, "SIGKILL": 9, "SIGUSR1": 10, "SIGSEGV": 11, "SIGUSR2": 12, "SIGPIPE": 13, "SIGALRM": 14, "SIGTERM": 15}
+	straceReadCalls      = map[string]bool{"read": true, "pread64": true, "readv": true, "preadv": true, "recv": true, "recvfrom": true}
+	straceWriteCalls     = map[string]bool{"write": true, "pwrite64": true, "writev": true, "pwritev": true, "send": true, "sendto": true}
+	straceVectorCalls    = map[string]bool{"readv": true, "preadv": true, "writev": true, "pwritev": true}
+)
+
+// straceCall is a system call waiting for its <... resumed> line.
+type straceCall struct {
+	name string
+	args string
+	at   time.Duration
+}
+
+// DecodeStrace reads the read and write calls of an strace log into a ByteRecord.
+// File descriptors 0, 1 and 2 become stdin, stdout and stderr, other file descriptors are kept as extra streams
+// named "fd<N>" if allFDs is set. Reads are placed at the time they returned, writes at the time they started.
+// The exit code is taken from the first traced process, exits of other processes are added as markers.
+// The lifetimes of the other processes are stored in the metadata, see StraceProcesses.
+func DecodeStrace(r io.Reader, allFDs bool) (Record, error) {
+	record := &ByteRecord{
+		JsonFormat: FormatBase64,
+		Out:        make(map[time.Duration][]byte),
+		In:         make(map[time.Duration][]byte),
+		Err:        make(map[time.Duration][]byte),
+	}
+
+	var (
+		first     time.Duration
+		previous  time.Duration
+		days      time.Duration
+		started   bool
+		mainPID   string
+		truncated int
+		pending   = make(map[string]straceCall)
+		processes = make(map[string]*StraceProcess)
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		match := straceLinePattern.FindStringSubmatch(line)
+		if match == nil {
+			// messages of strace itself like "strace: Process 1234 attached"
+			continue
+		}
+		pid := match[1] + match[2]
+
+		at, epoch, err := parseStraceTime(match[3])
+		if err != nil {
+			return nil, fmt.Errorf("strace: line %d: %w", lineNumber, err)
+		}
+		if !epoch {
+			// -tt has no date, a time far before the previous one passed midnight
+			at += days
+			if at < previous-straceMaxDayDeviation {
+				days += 24 * time.Hour
+				at += 24 * time.Hour
+			}
+		}
+		if !started {
+			started, first, mainPID = true, at, pid
+			if epoch {
+				record.SetMetadata(MetadataStartTime, time.Unix(0, int64(at)).Format(time.RFC3339))
+			}
+			if pid != "" {
+				record.SetMetadata(MetadataStracePID, pid)
+			}
+		}
+		previous = at
+		offset := at - first
+		if offset < 0 {
+			offset = 0
+		}
+		process := processes[pid]
+		if pid != mainPID && process == nil {
+			number, _ := strconv.Atoi(pid)
+			process = &StraceProcess{PID: number, Start: offset}
+			processes[pid] = process
+		}
+		if process != nil {
+			process.End = offset
+		}
+
+		text := match[4]
+		if exited := straceExitedPattern.FindStringSubmatch(text); exited != nil {
+			code, _ := strconv.Atoi(exited[1])
+			straceExit(record, process, code, offset, "exited with "+exited[1])
+			continue
+		}
+		if killed := straceKilledPattern.FindStringSubmatch(text); killed != nil {
+			straceExit(record, process, 128+straceSignalNumbers[killed[1]], offset, "killed by "+killed[1])
+			continue
+		}
+
+		if unfinished := straceUnfinished.FindStringSubmatch(text); unfinished != nil {
+			pending[pid] = straceCall{name: unfinished[1], args: unfinished[2], at: offset}
+			continue
+		}
+		if resumed := straceResumed.FindStringSubmatch(text); resumed != nil {
+			call, ok := pending[pid]
+			if !ok || call.name != resumed[1] {
+				// the start of the call was not logged
+				continue
+			}
+			delete(pending, pid)
+			text = call.name + "(" + call.args + resumed[2]
+			if !straceReadCalls[call.name] {
+				offset = call.at
+			}
+		}
+
+		call := straceCallPattern.FindStringSubmatch(text)
+		if call == nil {
+			// signals, strace messages and incomplete calls
+			continue
+		}
+		name, args := call[1], call[2]
+		result, err := strconv.Atoi(call[3])
+		if err != nil || result < 0 {
+			continue
+		}
+
+		if name == "execve" && pid == mainPID && record.Cmd == "" {
+			record.Cmd = parseStraceCommand(args)
+			continue
+		}
+		if !straceReadCalls[name] && !straceWriteCalls[name] {
+			continue
+		}
+
+		fdMatch := straceFDPattern.FindStringSubmatch(args)
+		if fdMatch == nil {
+			continue
+		}
+		fd, _ := strconv.Atoi(fdMatch[1])
+		stream := FDStream(fd)
+		if fd > 2 && !allFDs {
+			continue
+		}
+
+		data, cut, err := parseStracePayload(args[len(fdMatch[0]):], straceVectorCalls[name])
+		if err != nil {
+			return nil, fmt.Errorf("strace: line %d: %w", lineNumber, err)
+		}
+		if cut {
+			truncated++
+		}
+		if len(data) > result {
+			// partial writes only wrote the start of the buffer
+			data = data[:result]
+		}
+		if len(data) == 0 {
+			continue
+		}
+
+		appendChunk(record.chunks(stream), offset, data)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !started {
+		return nil, fmt.Errorf("strace: no lines with timestamps found, record with strace -tt")
+	}
+
+	if truncated > 0 {
+		record.SetMetadata(MetadataStraceTruncated, strconv.Itoa(truncated))
+	}
+	for _, process := range processes {
+		setStraceProcess(record, *process)
+	}
+
+	return record, nil
+}
+
+// straceExit records the exit of a process, the exit code of the traced process, which has no StraceProcess,
+// or the exit and a marker for others.
+func straceExit(record *ByteRecord, process *StraceProcess, code int, offset time.Duration, status string) {
+	if process == nil {
+		record.ExitC = code
+		return
+	}
+	process.Exited, process.Exit, process.ExitCode, process.Status = true, offset, code, status
+	record.AddMarker(Marker{Offset: offset, Label: "pid " + strconv.Itoa(process.PID) + " " + status})
+}
+
+// StraceProcess is a process of an strace import besides the traced one.
+type StraceProcess struct {
+	PID int
+	// Start and End are the offsets of the first and the last line of the process.
+	Start time.Duration
+	End   time.Duration
+	// Exited is set if the log has the exit of the process at Exit, with its ExitCode and a Status like
+	// "exited with 1" or "killed by SIGTERM".
+	Exited   bool
+	Exit     time.Duration
+	ExitCode int
+	Status   string
+}
+
+// setStraceProcess stores the process in the metadata under keys like "strace.process.<pid>.start".
+func setStraceProcess(record Record, process StraceProcess) {
+	prefix := MetadataStraceProcessPrefix + strconv.Itoa(process.PID) + "."
+	record.SetMetadata(prefix+"start", process.Start.String())
+	record.SetMetadata(prefix+"end", process.End.String())
+	if process.Exited {
+		record.SetMetadata(prefix+"exit", process.Exit.String())
+		record.SetMetadata(prefix+"exit_code", strconv.Itoa(process.ExitCode))
+		record.SetMetadata(prefix+"status", process.Status)
+	}
+}
+
+// moved returns the process with its start, end and exit moved by offset.
+func (p StraceProcess) moved(offset time.Duration) StraceProcess {
+	p.Start, p.End, p.Exit = p.Start+offset, p.End+offset, p.Exit+offset
+	return p
+}
+
+// remapStraceProcesses moves the processes stored in the metadata of remapped like remap moved the chunks of record.
+// A process whose start or end was removed is cut to the first and last kept chunk or marker of its lifetime,
+// processes of which nothing is kept are removed, and so are exits of removed parts.
+func remapStraceProcesses(remapped *ByteRecord, record Record, mapping func(time.Duration) (time.Duration, bool)) {
+	var offsets []time.Duration
+	for _, stream := range RecordStreams(record) {
+		for offset := range StreamData(record, stream) {
+			offsets = append(offsets, offset)
+		}
+	}
+	for _, marker := range record.Markers() {
+		offsets = append(offsets, marker.Offset)
+	}
+	sort.Slice(offsets, func(i, j int) bool {
+		return offsets[i] < offsets[j]
+	})
+
+	for _, process := range StraceProcesses(remapped) {
+		prefix := MetadataStraceProcessPrefix + strconv.Itoa(process.PID) + "."
+		for key := range remapped.Meta {
+			if strings.HasPrefix(key, prefix) {
+				delete(remapped.Meta, key)
+			}
+		}
+
+		first := sort.Search(len(offsets), func(i int) bool {
+			return offsets[i] >= process.Start
+		})
+		lifetime := []time.Duration{process.Start, process.End}
+		for _, offset := range offsets[first:] {
+			if offset > process.End {
+				break
+			}
+			lifetime = append(lifetime, offset)
+		}
+
+		var start, end time.Duration
+		kept := false
+		for _, offset := range lifetime {
+			mapped, ok := mapping(offset)
+			if !ok {
+				continue
+			}
+			if !kept || mapped < start {
+				start = mapped
+			}
+			if !kept || mapped > end {
+				end = mapped
+			}
+			kept = true
+		}
+		if !kept {
+			continue
+		}
+
+		exit, exitKept := mapping(process.Exit)
+		process.Start, process.End = start, end
+		process.Exit, process.Exited = exit, exitKept && process.Exited
+		setStraceProcess(remapped, process)
+	}
+}
+
+// StraceProcesses returns the processes besides the traced one stored in the metadata of an strace import,
+// sorted by pid. Keys with invalid values are ignored.
+func StraceProcesses(record Record) []StraceProcess {
+	processes := make(map[int]*StraceProcess)
+	for key, value := range record.Metadata() {
+		if !strings.HasPrefix(key, MetadataStraceProcessPrefix) {
+			continue
+		}
+		pidText, field, _ := strings.Cut(strings.TrimPrefix(key, MetadataStraceProcessPrefix), ".")
+		pid, err := strconv.Atoi(pidText)
+		if err != nil {
+			continue
+		}
+		process := processes[pid]
+		if process == nil {
+			process = &StraceProcess{PID: pid}
+			processes[pid] = process
+		}
+
+		switch field {
+		case "start":
+			process.Start, _ = time.ParseDuration(value)
+		case "end":
+			process.End, _ = time.ParseDuration(value)
+		case "exit":
+			process.Exit, err = time.ParseDuration(value)
+			process.Exited = err == nil
+		case "exit_code":
+			process.ExitCode, _ = strconv.Atoi(value)
+		case "status":
+			process.Status = value
+		}
+	}
+
+	sorted := make([]StraceProcess, 0, len(processes))
+	for _, process := range processes {
+		// the end of a process is never before its start or exit
+		if process.End < process.Start {
+			process.End = process.Start
+		}
+		if process.Exited && process.End < process.Exit {
+			process.End = process.Exit
+		}
+		sorted = append(sorted, *process)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].PID < sorted[j].PID
+	})
+	return sorted
+}
+
+// parseStraceTime parses a -tt time of day or a -ttt epoch time, reporting whether it was an epoch time.
+func parseStraceTime(value string) (time.Duration, bool, error) {
+	if !strings.Contains(value, ":") {
+		seconds, fraction, _ := strings.Cut(value, ".")
+		sec, err := strconv.ParseInt(seconds, 10, 64)
+		if err != nil {
+			return 0, true, fmt.Errorf("invalid timestamp %q", value)
+		}
+		return time.Duration(sec)*time.Second + parseStraceFraction(fraction), true, nil
+	}
+
+	clock, fraction, _ := strings.Cut(value, ".")
+	parts := strings.Split(clock, ":")
+	var at time.Duration
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid timestamp %q", value)
+		}
+		at += time.Duration(n) * unit
+	}
+	return at + parseStraceFraction(fraction), false, nil
+}
+
+// parseStraceFraction parses the digits after the decimal point as fraction of a second.
+func parseStraceFraction(digits string) time.Duration {
+	if len(digits) > 9 {
+		digits = digits[:9]
+	}
+	digits += strings.Repeat("0", 9-len(digits))
+	n, _ := strconv.Atoi(digits)
+	return time.Duration(n)
+}
+
+// parseStracePayload parses the data argument of a call, the first string or the iov_base strings of a vector.
+// It reports whether strace truncated the data.
+func parseStracePayload(args string, vector bool) ([]byte, bool, error) {
+	if !vector {
+		if !strings.HasPrefix(args, `"`) {
+			return nil, false, nil
+		}
+		data, rest, err := unquoteStrace(args)
+		return data, strings.HasPrefix(rest, "..."), err
+	}
+
+	var data []byte
+	truncated := false
+	for _, location := range straceIOVBasePattern.FindAllStringIndex(args, -1) {
+		rest := args[location[1]:]
+		if !strings.HasPrefix(rest, `"`) {
+			continue
+		}
+		chunk, rest, err := unquoteStrace(rest)
+		if err != nil {
+			return nil, false, err
+		}
+		data = append(data, chunk...)
+		if strings.HasPrefix(rest, "...") {
+			truncated = true
+		}
+	}
+	return data, truncated, nil
+}
+
+// parseStraceCommand returns the arguments of an execve call joined by spaces.
+func parseStraceCommand(args string) string {
+	start := straceExecvePattern.FindStringIndex(args)
+	if start == nil {
+		return ""
+	}
+	var command []string
+	rest := args[start[1]:]
+	for strings.HasPrefix(rest, `"`) {
+		arg, remaining, err := unquoteStrace(rest)
+		if err != nil {
+			break
+		}
+		command = append(command, string(arg))
+		rest = strings.TrimPrefix(strings.TrimPrefix(remaining, "..."), ", ")
+	}
+	return strings.Join(command, " ")
+}
+
+// unquoteStrace parses a quoted string with the C escapes used by strace, returning the text after it.
+func unquoteStrace(value string) ([]byte, string, error) {
+	var data []byte
+	for i := 1; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == '"':
+			return data, value[i+1:], nil
+		case c != '\\':
+			data = append(data, c)
+			continue
+		case i+1 >= len(value):
+			return nil, "", fmt.Errorf("unterminated escape in %q", value)
+		}
+
+		i++
+		switch escaped := value[i]; escaped {
+		case 'n':
+			data = append(data, '\n')
+		case 't':
+			data = append(data, '\t')
+		case 'r':
+			data = append(data, '\r')
+		case 'v':
+			data = append(data, '\v')
+		case 'f':
+			data = append(data, '\f')
+		case 'x':
+			if i+2 >= len(value) {
+				return nil, "", fmt.Errorf("invalid hex escape in %q", value)
+			}
+			n, err := strconv.ParseUint(value[i+1:i+3], 16, 8)
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid hex escape in %q", value)
+			}
+			data = append(data, byte(n))
+			i += 2
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			// octal escapes have up to three digits
+			end := i + 1
+			for end < len(value) && end < i+3 && value[end] >= '0' && value[end] <= '7' {
+				end++
+			}
+			n, _ := strconv.ParseUint(value[i:end], 8, 16)
+			data = append(data, byte(n))
+			i = end - 1
+		default:
+			data = append(data, escaped)
+		}
+	}
+	return nil, "", fmt.Errorf("unterminated string %q", value)
+}
@@ -0,0 +1,146 @@
+package recmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stream names one of the recorded pipes, matching the json keys of a record.
+type Stream string
+
+const (
+	StreamOut Stream = "out"
+	StreamIn  Stream = "in"
+	StreamErr Stream = "err"
+)
+
+// Streams lists all streams in the order they are replayed for equal offsets.
+var Streams = []Stream{StreamOut, StreamIn, StreamErr}
+
+// ExtraStreamer is implemented by records with streams besides out, in and err.
+// Extra streams are named after their file descriptor, like "fd3".
+type ExtraStreamer interface {
+	ExtraStreams() map[Stream]map[time.Duration][]byte
+}
+
+// FDStream returns the stream of a file descriptor, out, in and err for 1, 0 and 2.
+func FDStream(fd int) Stream {
+	switch fd {
+	case 0:
+		return StreamIn
+	case 1:
+		return StreamOut
+	case 2:
+		return StreamErr
+	default:
+		return Stream("fd" + strconv.Itoa(fd))
+	}
+}
+
+// ParseStream parses a stream name, also accepting "stdout", "stdin", "stderr" and file descriptors like "fd3".
+func ParseStream(name string) (Stream, error) {
+	switch name {
+	case "out", "stdout":
+		return StreamOut, nil
+	case "in", "stdin":
+		return StreamIn, nil
+	case "err", "stderr":
+		return StreamErr, nil
+	}
+	if fd, err := strconv.Atoi(strings.TrimPrefix(name, "fd")); strings.HasPrefix(name, "fd") && err == nil && fd >= 0 {
+		return FDStream(fd), nil
+	}
+	return "", fmt.Errorf("unknown stream: %s", name)
+}
+
+// StreamData returns the chunks of the given stream.
+func StreamData(record Record, stream Stream) map[time.Duration][]byte {
+	switch stream {
+	case StreamOut:
+		return record.StdOut()
+	case StreamIn:
+		return record.StdIn()
+	case StreamErr:
+		return record.StdErr()
+	}
+	if extra, ok := record.(ExtraStreamer); ok {
+		return extra.ExtraStreams()[stream]
+	}
+	return nil
+}
+
+// ExtraStreams returns the names of the extra streams of the record, sorted by name.
+func ExtraStreams(record Record) []Stream {
+	extra, ok := record.(ExtraStreamer)
+	if !ok {
+		return nil
+	}
+	var streams []Stream
+	for stream := range extra.ExtraStreams() {
+		streams = append(streams, stream)
+	}
+	sort.Slice(streams, func(i, j int) bool {
+		return streams[i] < streams[j]
+	})
+	return streams
+}
+
+// RecordStreams returns out, in and err followed by the extra streams of the record.
+func RecordStreams(record Record) []Stream {
+	return append(append([]Stream{}, Streams...), ExtraStreams(record)...)
+}
+
+// extraStreamsError returns an error naming the extra streams of the record if it has any, as what can't keep them.
+func extraStreamsError(record Record, what string) error {
+	extra := ExtraStreams(record)
+	if len(extra) == 0 {
+		return nil
+	}
+	names := make([]string, len(extra))
+	for i, stream := range extra {
+		names[i] = string(stream)
+	}
+	return fmt.Errorf("%s can't keep the extra streams %s, only base64 json records do", what, strings.Join(names, ", "))
+}
+
+// Event is a single chunk of a stream.
+type Event struct {
+	Offset time.Duration
+	Stream Stream
+	Data   []byte
+}
+
+// Events returns the chunks of the given streams (out, in and err if none are given) sorted by offset.
+func Events(record Record, streams ...Stream) []Event {
+	if len(streams) == 0 {
+		streams = Streams
+	}
+
+	var events []Event
+	for _, stream := range streams {
+		for offset, data := range StreamData(record, stream) {
+			events = append(events, Event{Offset: offset, Stream: stream, Data: data})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Offset != events[j].Offset {
+			return events[i].Offset < events[j].Offset
+		}
+		return streamOrder(events[i].Stream) < streamOrder(events[j].Stream)
+	})
+
+	return events
+}
+
+func streamOrder(stream Stream) int {
+	for i, s := range Streams {
+		if s == stream {
+			return i
+		}
+	}
+	return len(Streams)
+}
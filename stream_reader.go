@@ -0,0 +1,122 @@
+package recmd
+
+import (
+	"io"
+	"time"
+)
+
+// StreamReader replays an ndjson record while decoding it, keeping only the current event in memory.
+// It offers the same controls as RecordReader.
+type StreamReader struct {
+	decoder     *NDJSONDecoder
+	current     []byte
+	previous    time.Duration
+	hasPrevious bool
+	ignoreTime  bool
+	timing      Timing
+	onMarker    func(Marker)
+	startAt     time.Duration
+	stopAt      time.Duration
+	hasStop     bool
+	exitCode    int
+}
+
+// NewStreamReader reads the header of the ndjson record from r and returns a reader of its events.
+func NewStreamReader(r io.Reader) (*StreamReader, error) {
+	decoder, err := NewNDJSONDecoder(r)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamReader{decoder: decoder}, nil
+}
+
+// Command returns the recorded command.
+func (sr *StreamReader) Command() string {
+	return sr.decoder.Command()
+}
+
+// ExitCode returns the recorded exit code, only known after the reader returned io.EOF.
+func (sr *StreamReader) ExitCode() int {
+	return sr.exitCode
+}
+
+// Follow waits for more data at the end of the input until the record is complete.
+func (sr *StreamReader) Follow() {
+	sr.decoder.Follow()
+}
+
+func (sr *StreamReader) IgnoreTime() {
+	sr.ignoreTime = true
+}
+
+func (sr *StreamReader) RespectTime() {
+	sr.ignoreTime = false
+}
+
+// SetTiming sets the policy applied to the delays between the events.
+func (sr *StreamReader) SetTiming(timing Timing) {
+	sr.timing = timing
+}
+
+// StartAt skips all events and markers before the given offset, it has to be called before reading.
+func (sr *StreamReader) StartAt(offset time.Duration) {
+	sr.startAt = offset
+}
+
+// StopAt ends the reading at the first event at or after the given offset.
+func (sr *StreamReader) StopAt(offset time.Duration) {
+	sr.stopAt = offset
+	sr.hasStop = true
+}
+
+// OnMarker sets a function which is called with every marker when it is read.
+func (sr *StreamReader) OnMarker(handler func(Marker)) {
+	sr.onMarker = handler
+}
+
+// Read reads the data of the events in the order they are stored, waiting for the time between them.
+func (sr *StreamReader) Read(p []byte) (int, error) {
+	for len(sr.current) == 0 {
+		entry, err := sr.decoder.Next()
+		if err != nil {
+			return 0, err
+		}
+
+		if entry.Type == EntryTrailer {
+			if entry.ExitCode != nil {
+				sr.exitCode = *entry.ExitCode
+			}
+			return 0, io.EOF
+		}
+
+		if entry.Offset < sr.startAt {
+			continue
+		}
+		if sr.hasStop && entry.Offset >= sr.stopAt {
+			if entry.Type == EntryMarker {
+				continue
+			}
+			return 0, io.EOF
+		}
+
+		switch entry.Type {
+		case EntryMarker:
+			if sr.onMarker != nil {
+				sr.onMarker(Marker{Offset: entry.Offset, Label: entry.Label})
+			}
+		case EntryEvent:
+			if sr.hasPrevious && !sr.ignoreTime && entry.Offset > sr.previous {
+				<-time.NewTimer(sr.timing.Delay(entry.Offset - sr.previous)).C
+			}
+			if !sr.hasPrevious || entry.Offset > sr.previous {
+				sr.previous = entry.Offset
+			}
+			sr.hasPrevious = true
+			sr.current = entry.Data
+		}
+	}
+
+	n := copy(p, sr.current)
+	sr.current = sr.current[n:]
+	return n, nil
+}
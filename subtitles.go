@@ -0,0 +1,250 @@
+package recmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SubtitleFormat is a subtitle file format.
+type SubtitleFormat string
+
+const (
+	SubtitleSRT SubtitleFormat = "srt"
+	SubtitleVTT SubtitleFormat = "vtt"
+)
+
+// ParseSubtitleFormat parses the name of a subtitle format.
+func ParseSubtitleFormat(name string) (SubtitleFormat, error) {
+	switch format := SubtitleFormat(name); format {
+	case SubtitleSRT, SubtitleVTT:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown subtitle format: %s, expected srt or vtt", name)
+	}
+}
+
+// SubtitleOptions configures how the lines of a record are turned into cues.
+type SubtitleOptions struct {
+	// Streams are the streams shown, out, in and err if empty.
+	Streams []Stream
+	// Timing moves the offsets, like for a video rendered with an idle limit.
+	Timing Timing
+	// MinDuration is how long a cue is at least shown, lines starting earlier are added to it up to MaxLines.
+	// The next cue starts after it and shows the last lines which appeared until then.
+	MinDuration time.Duration
+	// MaxDuration caps how long a cue is shown if the next one starts later, no cap if zero or below MinDuration.
+	MaxDuration time.Duration
+	// MaxLength wraps longer lines at spaces, no limit if zero.
+	MaxLength int
+	// MaxLines is the number of lines of a cue, 2 if zero. More lines are split into several cues.
+	MaxLines int
+	// Labels prefixes every line with its stream, like "[stderr] ".
+	Labels bool
+}
+
+// Cue is a subtitle shown from Start to End.
+type Cue struct {
+	Start time.Duration
+	End   time.Duration
+	Lines []string
+}
+
+// subtitleRow is a line of a cue, shown from the offset of the first byte of its record line.
+type subtitleRow struct {
+	offset time.Duration
+	text   string
+	// line is the index of the record line, wrapped lines have several rows
+	line int
+}
+
+// Cues reconstructs the lines of the record, without escape sequences, and groups them into cues.
+// Empty lines are skipped. Lines starting within MinDuration of a cue are merged into it up to MaxLines.
+// No cue but the last is shown shorter than MinDuration: the next one starts after it with the last MaxLines
+// lines which appeared until then, like the bottom of a terminal. Lines scrolled past in between are left out,
+// but the rows of a line wrapped into more than MaxLines rows are all shown.
+func Cues(record Record, options SubtitleOptions) []Cue {
+	if options.MaxLines <= 0 {
+		options.MaxLines = 2
+	}
+
+	lines := Lines(options.Timing.Retime(Events(record, options.Streams...)), ANSIRender)
+	// lines are returned in the order they are completed, cues follow their first bytes
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Offset < lines[j].Offset
+	})
+
+	var rows []subtitleRow
+	for i, line := range lines {
+		text := subtitleText(line.Text)
+		if text == "" {
+			continue
+		}
+		if options.Labels {
+			text = "[" + streamLabel(line.Stream) + "] " + text
+		}
+		for _, wrapped := range wrapText(text, options.MaxLength) {
+			rows = append(rows, subtitleRow{offset: line.Offset, text: wrapped, line: i})
+		}
+	}
+
+	var cues []Cue
+	for i := 0; i < len(rows); {
+		start := rows[i].offset
+		if len(cues) > 0 && start < cues[len(cues)-1].Start+options.MinDuration {
+			start = cues[len(cues)-1].Start + options.MinDuration
+		}
+
+		// of the rows which appeared until the start only the last ones fit
+		appeared := i
+		for appeared < len(rows) && rows[appeared].offset <= start {
+			appeared++
+		}
+		continued := i > 0 && rows[i-1].line == rows[i].line
+		if appeared-i > options.MaxLines && !continued {
+			skip := appeared - options.MaxLines
+			first := appeared - 1
+			for first > i && rows[first-1].line == rows[appeared-1].line {
+				first--
+			}
+			if first < skip {
+				skip = first
+			}
+			i = skip
+		}
+
+		cue := Cue{Start: start}
+		for i < len(rows) && len(cue.Lines) < options.MaxLines && (len(cue.Lines) == 0 || rows[i].offset < start+options.MinDuration) {
+			cue.Lines = append(cue.Lines, rows[i].text)
+			i++
+		}
+		cues = append(cues, cue)
+	}
+
+	longest := options.MaxDuration
+	if longest < options.MinDuration {
+		longest = options.MinDuration
+	}
+	for i := 0; i < len(cues); {
+		// cues of the same start are split from one line and share its time, which only happens without MinDuration
+		j := i + 1
+		for j < len(cues) && cues[j].Start == cues[i].Start {
+			j++
+		}
+
+		start := cues[i].Start
+		slot := longest
+		if j < len(cues) && (options.MaxDuration <= 0 || cues[j].Start-start < longest) {
+			slot = cues[j].Start - start
+		}
+
+		share := slot / time.Duration(j-i)
+		for k := i; k < j; k++ {
+			cues[k].Start = start + time.Duration(k-i)*share
+			cues[k].End = cues[k].Start + share
+		}
+		i = j
+	}
+
+	return cues
+}
+
+// subtitleText removes control characters left by rendering and trailing spaces, tabs become spaces.
+func subtitleText(text string) string {
+	text = strings.ToValidUTF8(text, "�")
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t':
+			return ' '
+		case unicode.IsControl(r):
+			return -1
+		default:
+			return r
+		}
+	}, text)
+	return strings.TrimRightFunc(text, unicode.IsSpace)
+}
+
+// streamLabel returns the name of the stream as it is known in a shell, like stdout.
+func streamLabel(stream Stream) string {
+	switch stream {
+	case StreamOut:
+		return "stdout"
+	case StreamIn:
+		return "stdin"
+	case StreamErr:
+		return "stderr"
+	default:
+		return string(stream)
+	}
+}
+
+// wrapText splits the text into lines of at most length runes, at the last space if there is one.
+func wrapText(text string, length int) []string {
+	runes := []rune(text)
+	if length <= 0 {
+		return []string{text}
+	}
+
+	var lines []string
+	for len(runes) > length {
+		end := length
+		for i := length; i > 0; i-- {
+			if runes[i] == ' ' {
+				end = i
+				break
+			}
+		}
+		lines = append(lines, strings.TrimRight(string(runes[:end]), " "))
+		runes = []rune(strings.TrimLeft(string(runes[end:]), " "))
+	}
+	if len(runes) > 0 {
+		lines = append(lines, string(runes))
+	}
+	return lines
+}
+
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// EncodeSubtitles writes the cues as a SubRip or WebVTT file.
+func EncodeSubtitles(w io.Writer, cues []Cue, format SubtitleFormat) error {
+	out := bufio.NewWriter(w)
+	if format == SubtitleVTT {
+		out.WriteString("WEBVTT\n\n")
+	}
+
+	for i, cue := range cues {
+		if format == SubtitleSRT {
+			fmt.Fprintf(out, "%d\n", i+1)
+		}
+		fmt.Fprintf(out, "%s --> %s\n", subtitleTime(cue.Start, format), subtitleTime(cue.End, format))
+		for _, line := range cue.Lines {
+			if format == SubtitleVTT {
+				// WebVTT cue text is markup, so < and & have to be escaped
+				line = vttEscaper.Replace(line)
+			} else {
+				// "-->" is not allowed in SubRip cue text
+				line = strings.ReplaceAll(line, "-->", "->")
+			}
+			out.WriteString(line + "\n")
+		}
+		out.WriteString("\n")
+	}
+
+	return out.Flush()
+}
+
+// subtitleTime formats an offset as hours:minutes:seconds with milliseconds after a comma for SubRip
+// and a dot for WebVTT.
+func subtitleTime(offset time.Duration, format SubtitleFormat) string {
+	milliseconds := offset.Milliseconds()
+	separator := ","
+	if format == SubtitleVTT {
+		separator = "."
+	}
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", milliseconds/3600000, milliseconds/60000%60, milliseconds/1000%60, separator, milliseconds%1000)
+}
@@ -0,0 +1,205 @@
+package recmd
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TableRows selects what a row of a table export stands for.
+type TableRows string
+
+const (
+	// TableChunks writes a row per chunk.
+	TableChunks TableRows = "chunks"
+	// TableLines writes a row per line, at the offset of the chunk with its first byte.
+	TableLines TableRows = "lines"
+	// TableBuckets writes a row per stream and time bucket with the number of chunks and bytes.
+	TableBuckets TableRows = "buckets"
+)
+
+// ParseTableRows parses the name of a TableRows.
+func ParseTableRows(name string) (TableRows, error) {
+	switch rows := TableRows(name); rows {
+	case TableChunks, TableLines, TableBuckets:
+		return rows, nil
+	default:
+		return "", fmt.Errorf("unknown rows: %s, expected chunks, lines or buckets", name)
+	}
+}
+
+// TableOptions configures EncodeTable.
+type TableOptions struct {
+	// Streams are the streams in the table, out, in and err if empty.
+	Streams []Stream
+	Rows    TableRows
+	// Bucket is the duration of a bucket for TableBuckets.
+	Bucket time.Duration
+	// Base64 writes the data base64 encoded instead of escaped text.
+	Base64 bool
+	// Comma separates the fields, ',' if zero and '\t' for TSV.
+	Comma rune
+}
+
+// tableMaxBuckets limits the number of buckets of a table, as every stream gets a row in every bucket.
+const tableMaxBuckets = 1000000
+
+// EncodeTable writes the chunks, lines or time buckets of the record as CSV with a header row.
+// Chunks and lines have the columns sequence, offset_ns, offset_s, delta_ns (since the previous row), stream, bytes
+// and text or base64. Text escapes backslashes, control characters and invalid UTF-8 like Go strings, so every
+// row is a single line. Buckets have the columns sequence, offset_ns, offset_s, stream, chunks and bytes
+// with a row for every stream in every bucket, also empty ones, up to the end of the record. Bucket durations
+// splitting the record into more than a million buckets are rejected.
+func EncodeTable(w io.Writer, record Record, options TableOptions) error {
+	if options.Rows == "" {
+		options.Rows = TableChunks
+	}
+	if options.Comma == 0 {
+		options.Comma = ','
+	}
+
+	out := csv.NewWriter(w)
+	out.Comma = options.Comma
+
+	events := Events(record, options.Streams...)
+	switch options.Rows {
+	case TableChunks, TableLines:
+		payload := "text"
+		if options.Base64 {
+			payload = "base64"
+		}
+		out.Write([]string{"sequence", "offset_ns", "offset_s", "delta_ns", "stream", "bytes", payload})
+
+		if options.Rows == TableLines {
+			events = lineEvents(events)
+		}
+		var previous time.Duration
+		for i, event := range events {
+			data := escapeText(event.Data)
+			if options.Base64 {
+				data = base64.StdEncoding.EncodeToString(event.Data)
+			}
+			out.Write([]string{
+				strconv.Itoa(i + 1),
+				strconv.FormatInt(int64(event.Offset), 10),
+				tableSeconds(event.Offset),
+				strconv.FormatInt(int64(event.Offset-previous), 10),
+				string(event.Stream),
+				strconv.Itoa(len(event.Data)),
+				data,
+			})
+			previous = event.Offset
+		}
+
+	case TableBuckets:
+		if options.Bucket <= 0 {
+			return fmt.Errorf("bucket duration must be positive, got %s", options.Bucket)
+		}
+		out.Write([]string{"sequence", "offset_ns", "offset_s", "stream", "chunks", "bytes"})
+
+		streams := options.Streams
+		if len(streams) == 0 {
+			streams = Streams
+		}
+		end := Duration(record)
+		if len(events) > 0 && events[len(events)-1].Offset > end {
+			end = events[len(events)-1].Offset
+		}
+		if count := end/options.Bucket + 1; count > tableMaxBuckets {
+			return fmt.Errorf("bucket duration %s splits the %s of the record into %d buckets, at most %d are allowed", options.Bucket, end, count, tableMaxBuckets)
+		}
+		buckets := int(end/options.Bucket) + 1
+		chunks := make(map[Stream][]int)
+		bytes := make(map[Stream][]int)
+		for _, stream := range streams {
+			chunks[stream] = make([]int, buckets)
+			bytes[stream] = make([]int, buckets)
+		}
+		for _, event := range events {
+			bucket := int(event.Offset / options.Bucket)
+			chunks[event.Stream][bucket]++
+			bytes[event.Stream][bucket] += len(event.Data)
+		}
+
+		sequence := 0
+		for bucket := 0; bucket < buckets; bucket++ {
+			offset := time.Duration(bucket) * options.Bucket
+			for _, stream := range streams {
+				sequence++
+				out.Write([]string{
+					strconv.Itoa(sequence),
+					strconv.FormatInt(int64(offset), 10),
+					tableSeconds(offset),
+					string(stream),
+					strconv.Itoa(chunks[stream][bucket]),
+					strconv.Itoa(bytes[stream][bucket]),
+				})
+			}
+		}
+
+	default:
+		return fmt.Errorf("unknown rows: %s, expected chunks, lines or buckets", options.Rows)
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// lineEvents splits the data of the events into lines without their line feed, sorted by the offset of their
+// first byte.
+func lineEvents(events []Event) []Event {
+	lines := Lines(events, ANSIKeep)
+	sort.SliceStable(lines, func(i, j int) bool {
+		if lines[i].Offset != lines[j].Offset {
+			return lines[i].Offset < lines[j].Offset
+		}
+		return streamOrder(lines[i].Stream) < streamOrder(lines[j].Stream)
+	})
+
+	lineEvents := make([]Event, len(lines))
+	for i, line := range lines {
+		lineEvents[i] = Event{Offset: line.Offset, Stream: line.Stream, Data: []byte(line.Text)}
+	}
+	return lineEvents
+}
+
+// tableSeconds formats the offset as seconds with nanoseconds.
+func tableSeconds(offset time.Duration) string {
+	return strconv.FormatFloat(offset.Seconds(), 'f', 9, 64)
+}
+
+// escapeText returns the data as text with backslashes, control characters and invalid UTF-8 escaped like in Go
+// strings, for example "\n", "\x1b" or "\xff".
+func escapeText(data []byte) string {
+	var builder strings.Builder
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&builder, `\x%02x`, data[0])
+		case r == '\\':
+			builder.WriteString(`\\`)
+		case r == '\n':
+			builder.WriteString(`\n`)
+		case r == '\r':
+			builder.WriteString(`\r`)
+		case r == '\t':
+			builder.WriteString(`\t`)
+		case r < 0x80 && unicode.IsControl(r):
+			fmt.Fprintf(&builder, `\x%02x`, r)
+		case unicode.IsControl(r):
+			fmt.Fprintf(&builder, `\u%04x`, r)
+		default:
+			builder.WriteRune(r)
+		}
+		data = data[size:]
+	}
+	return builder.String()
+}
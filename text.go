@@ -0,0 +1,232 @@
+package recmd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// ANSIMode selects how escape sequences and control characters are handled when turning chunks into text.
+type ANSIMode string
+
+const (
+	// ANSIStrip removes escape sequences, control characters are kept.
+	ANSIStrip ANSIMode = "strip"
+	// ANSIKeep keeps the content unchanged.
+	ANSIKeep ANSIMode = "keep"
+	// ANSIRender removes escape sequences and applies carriage returns, backspaces and line erases like a terminal.
+	ANSIRender ANSIMode = "render"
+)
+
+// ParseANSIMode parses the name of an ANSIMode.
+func ParseANSIMode(name string) (ANSIMode, error) {
+	switch mode := ANSIMode(name); mode {
+	case ANSIStrip, ANSIKeep, ANSIRender:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown ansi mode: %s", name)
+	}
+}
+
+// Line is a line of text of a stream.
+type Line struct {
+	// Offset is the offset of the chunk containing the first byte of the line.
+	Offset time.Duration
+	Stream Stream
+	Text   string
+}
+
+// Lines joins the events of each stream into lines, in the order the lines are completed.
+// Unterminated lines at the end are returned last, sorted by offset.
+func Lines(events []Event, mode ANSIMode) []Line {
+	var lines []Line
+	emit := func(line Line) {
+		lines = append(lines, line)
+	}
+
+	builders := make(map[Stream]*lineBuilder)
+	for _, event := range events {
+		builder, ok := builders[event.Stream]
+		if !ok {
+			builder = &lineBuilder{mode: mode, stream: event.Stream}
+			builders[event.Stream] = builder
+		}
+		builder.write(event.Offset, event.Data, emit)
+	}
+
+	var rest []Line
+	for _, builder := range builders {
+		builder.flush(func(line Line) {
+			rest = append(rest, line)
+		})
+	}
+	sort.SliceStable(rest, func(i, j int) bool {
+		if rest[i].Offset != rest[j].Offset {
+			return rest[i].Offset < rest[j].Offset
+		}
+		return streamOrder(rest[i].Stream) < streamOrder(rest[j].Stream)
+	})
+
+	return append(lines, rest...)
+}
+
+// StripANSI removes all escape sequences from data.
+func StripANSI(data []byte) []byte {
+	stripped := make([]byte, 0, len(data))
+	var escape []byte
+	for _, c := range data {
+		switch {
+		case len(escape) > 0:
+			escape = append(escape, c)
+			if escapeComplete(escape) {
+				escape = nil
+			}
+		case c == 0x1b:
+			escape = append(escape, c)
+		default:
+			stripped = append(stripped, c)
+		}
+	}
+	return stripped
+}
+
+// lineBuilder collects the bytes of a single stream into lines.
+type lineBuilder struct {
+	mode   ANSIMode
+	stream Stream
+
+	escape  []byte
+	partial []byte
+
+	raw    []byte
+	runes  []rune
+	cursor int
+
+	offset  time.Duration
+	started bool
+}
+
+func (b *lineBuilder) write(offset time.Duration, data []byte, emit func(Line)) {
+	for _, c := range data {
+		if !b.started {
+			b.started = true
+			b.offset = offset
+		}
+
+		if len(b.escape) > 0 {
+			b.escape = append(b.escape, c)
+			if escapeComplete(b.escape) {
+				b.applyEscape(b.escape)
+				b.escape = nil
+			}
+			continue
+		}
+
+		if c == 0x1b && b.mode != ANSIKeep {
+			b.escape = append(b.escape, c)
+			continue
+		}
+
+		if c == '\n' {
+			b.emit(emit)
+			continue
+		}
+
+		if b.mode != ANSIRender {
+			b.raw = append(b.raw, c)
+			continue
+		}
+
+		switch c {
+		case '\r':
+			b.partial = nil
+			b.cursor = 0
+		case '\b':
+			b.partial = nil
+			if b.cursor > 0 {
+				b.cursor--
+			}
+		default:
+			b.partial = append(b.partial, c)
+			if utf8.FullRune(b.partial) {
+				r, _ := utf8.DecodeRune(b.partial)
+				b.partial = nil
+				b.put(r)
+			}
+		}
+	}
+}
+
+// put writes the rune at the cursor, overwriting what was there before.
+func (b *lineBuilder) put(r rune) {
+	if b.cursor < len(b.runes) {
+		b.runes[b.cursor] = r
+	} else {
+		b.runes = append(b.runes, r)
+	}
+	b.cursor++
+}
+
+func (b *lineBuilder) applyEscape(sequence []byte) {
+	if b.mode != ANSIRender || len(sequence) < 3 || sequence[1] != '[' || sequence[len(sequence)-1] != 'K' {
+		return
+	}
+
+	// Erase in line
+	switch string(sequence[2 : len(sequence)-1]) {
+	case "", "0":
+		if b.cursor < len(b.runes) {
+			b.runes = b.runes[:b.cursor]
+		}
+	case "1":
+		for i := 0; i < b.cursor && i < len(b.runes); i++ {
+			b.runes[i] = ' '
+		}
+	case "2":
+		b.runes = nil
+	}
+}
+
+func (b *lineBuilder) emit(emit func(Line)) {
+	text := string(b.raw)
+	if b.mode == ANSIRender {
+		text = string(b.runes)
+	}
+	emit(Line{Offset: b.offset, Stream: b.stream, Text: text})
+
+	b.raw = nil
+	b.runes = nil
+	b.partial = nil
+	b.cursor = 0
+	b.started = false
+}
+
+func (b *lineBuilder) flush(emit func(Line)) {
+	if b.started {
+		b.emit(emit)
+	}
+}
+
+// escapeComplete reports whether the escape sequence starting with ESC is complete.
+func escapeComplete(sequence []byte) bool {
+	if len(sequence) < 2 {
+		return false
+	}
+
+	last := sequence[len(sequence)-1]
+	switch sequence[1] {
+	case '[':
+		// CSI: parameters and intermediates followed by a final byte
+		return len(sequence) > 2 && last >= 0x40 && last <= 0x7e
+	case ']', 'P', 'X', '^', '_':
+		// OSC and other strings: terminated by BEL or ST
+		if sequence[1] == ']' && last == '\a' {
+			return true
+		}
+		return len(sequence) > 3 && sequence[len(sequence)-2] == 0x1b && last == '\\'
+	default:
+		// intermediates followed by a final byte
+		return last < 0x20 || last > 0x2f
+	}
+}
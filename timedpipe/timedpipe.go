@@ -13,8 +13,13 @@ type Pipe struct {
 	started   bool
 	output    io.Writer
 	input     io.Reader
+	onWrite   DataHook
+	onRead    DataHook
 }
 
+// DataHook is called with every chunk of data passing the Pipe and the time passed since its start time.
+type DataHook func(offset time.Duration, data []byte)
+
 type PipeOption func(*Pipe)
 
 // WithOutput sets the output writer for the Pipe.
@@ -37,6 +42,24 @@ func WithInput(r io.Reader) PipeOption {
 	}
 }
 
+// WithWriteHook returns a PipeOption function that sets a hook called for every write to the Pipe.
+//
+// hook is called after the data is stored and before it is passed to the output.
+func WithWriteHook(hook DataHook) PipeOption {
+	return func(t *Pipe) {
+		t.onWrite = hook
+	}
+}
+
+// WithReadHook returns a PipeOption function that sets a hook called for every read from the Pipe.
+//
+// hook is called after the data is stored.
+func WithReadHook(hook DataHook) PipeOption {
+	return func(t *Pipe) {
+		t.onRead = hook
+	}
+}
+
 // StartNow returns a PipeOption function that sets the start time of the Pipe to the current time.
 //
 // It takes no parameters and returns a PipeOption.
@@ -52,7 +75,7 @@ func StartNow() PipeOption {
 // Returns: a PipeOption function.
 func SetStartTime(time time.Time) PipeOption {
 	return func(t *Pipe) {
-		t.start = time
+		t.SetStartTime(time)
 	}
 }
 
@@ -92,7 +115,12 @@ func (t *Pipe) Write(p []byte) (n int, err error) {
 	copied := make([]byte, len(p))
 	copy(copied, p)
 
-	t.dataWrite[time.Since(t.start)] = copied
+	offset := time.Since(t.start)
+	t.dataWrite[offset] = copied
+
+	if t.onWrite != nil {
+		t.onWrite(offset, copied)
+	}
 
 	if t.output != nil {
 		return t.output.Write(p)
@@ -118,7 +146,11 @@ func (t *Pipe) Read(p []byte) (n int, err error) {
 		}
 		copied := make([]byte, n)
 		copy(copied, p)
-		t.dataRead[time.Since(t.start)] = copied
+		offset := time.Since(t.start)
+		t.dataRead[offset] = copied
+		if t.onRead != nil {
+			t.onRead(offset, copied)
+		}
 		return
 	}
 
@@ -164,5 +196,6 @@ func (t *Pipe) SetOutput(w io.Writer) *Pipe {
 // Returns a pointer to the Pipe.
 func (t *Pipe) SetStartTime(time time.Time) *Pipe {
 	t.start = time
+	t.started = true
 	return t
 }
@@ -0,0 +1,51 @@
+package recmd
+
+import (
+	"fmt"
+	"time"
+)
+
+// Timing is the policy for the delays between events when replaying or rendering a record.
+// The zero value keeps the recorded delays.
+type Timing struct {
+	// Speed divides every delay, 1 if zero.
+	Speed float64
+	// IdleLimit caps every recorded delay before the speed is applied, no limit if zero.
+	IdleLimit time.Duration
+}
+
+// Validate returns an error for a negative speed or idle limit.
+func (t Timing) Validate() error {
+	if t.Speed < 0 {
+		return fmt.Errorf("speed must be positive, got %g", t.Speed)
+	}
+	if t.IdleLimit < 0 {
+		return fmt.Errorf("idle limit must be positive, got %s", t.IdleLimit)
+	}
+	return nil
+}
+
+// Delay returns how long to wait for a recorded delay.
+func (t Timing) Delay(recorded time.Duration) time.Duration {
+	if t.IdleLimit > 0 && recorded > t.IdleLimit {
+		recorded = t.IdleLimit
+	}
+	if t.Speed > 0 && t.Speed != 1 {
+		recorded = time.Duration(float64(recorded) / t.Speed)
+	}
+	return recorded
+}
+
+// Retime returns a copy of the events sorted by offset with the offsets moved by the policy.
+func (t Timing) Retime(events []Event) []Event {
+	retimed := make([]Event, len(events))
+	var previous, offset time.Duration
+	for i, event := range events {
+		if event.Offset > previous {
+			offset += t.Delay(event.Offset - previous)
+			previous = event.Offset
+		}
+		retimed[i] = Event{Offset: offset, Stream: event.Stream, Data: event.Data}
+	}
+	return retimed
+}
@@ -0,0 +1,173 @@
+package recmd
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// The Trace Event Format is the JSON format of chrome://tracing, also opened by Perfetto:
+//
+//	{"traceEvents": [{"name": "stdout", "ph": "i", "ts": 1234.5, "pid": 1, "tid": 1, "s": "t"}, ...]}
+//
+// Timestamps and durations are microseconds. "ph" is the kind of the event: X a slice with a duration,
+// i an instant, C a counter and M metadata naming processes and threads.
+
+const (
+	// traceMainPID is the pid of the recorded command in traces, unless the record has the pid from strace.
+	traceMainPID = 1
+	// tracePreviewLength is the number of characters of a chunk shown in its event.
+	tracePreviewLength = 100
+)
+
+type traceEvent struct {
+	Name  string         `json:"name"`
+	Cat   string         `json:"cat,omitempty"`
+	Phase string         `json:"ph"`
+	Ts    float64        `json:"ts"`
+	Dur   *float64       `json:"dur,omitempty"`
+	PID   int            `json:"pid"`
+	TID   int            `json:"tid"`
+	Scope string         `json:"s,omitempty"`
+	Args  map[string]any `json:"args,omitempty"`
+}
+
+type traceFile struct {
+	TraceEvents     []traceEvent      `json:"traceEvents"`
+	DisplayTimeUnit string            `json:"displayTimeUnit"`
+	OtherData       map[string]string `json:"otherData,omitempty"`
+}
+
+// EncodeTrace writes the record as a Trace Event Format file.
+// The process of the command has a track for its lifetime and one per stream with an event per chunk, instants or
+// slices lasting until the next chunk of the track if slices is set, and a counter of the bytes of every stream.
+// Markers are global instants. The other processes of an strace import, see StraceProcesses, get their own
+// lifetime tracks.
+func EncodeTrace(w io.Writer, record Record, slices bool) error {
+	streams := RecordStreams(record)
+	end := Duration(record)
+	mainPID := traceMainPID
+	if pid, err := strconv.Atoi(record.Metadata()[MetadataStracePID]); err == nil {
+		mainPID = pid
+	}
+
+	command := record.Command()
+	if command == "" {
+		command = "command"
+	}
+
+	events := []traceEvent{
+		traceMetadata("process_name", mainPID, 0, command),
+		traceMetadata("thread_name", mainPID, 0, "process"),
+		{Name: command, Cat: "lifecycle", Phase: "X", Dur: traceDuration(end), PID: mainPID, Args: map[string]any{"exit_code": record.ExitCode()}},
+		{Name: "start", Cat: "lifecycle", Phase: "i", PID: mainPID, Scope: "p"},
+		{Name: "exit", Cat: "lifecycle", Phase: "i", Ts: traceTime(end), PID: mainPID, Scope: "p", Args: map[string]any{"exit_code": record.ExitCode()}},
+	}
+
+	for _, process := range StraceProcesses(record) {
+		if process.PID == mainPID {
+			continue
+		}
+		name := "pid " + strconv.Itoa(process.PID)
+		args := map[string]any{}
+		if process.Exited {
+			args["status"] = process.Status
+			args["exit_code"] = process.ExitCode
+		}
+		events = append(events,
+			traceMetadata("process_name", process.PID, 0, name),
+			traceMetadata("thread_name", process.PID, 0, "process"),
+			traceEvent{Name: name, Cat: "lifecycle", Phase: "X", Ts: traceTime(process.Start), Dur: traceDuration(process.End - process.Start), PID: process.PID, Args: args},
+		)
+		if process.Exited {
+			events = append(events, traceEvent{Name: "exit", Cat: "lifecycle", Phase: "i", Ts: traceTime(process.Exit), PID: process.PID, Scope: "p", Args: args})
+		}
+	}
+
+	for i, stream := range streams {
+		events = append(events, traceMetadata("thread_name", mainPID, i+1, streamLabel(stream)))
+		chunks := StreamData(record, stream)
+		offsets := sortedOffsets(chunks)
+		for j, offset := range offsets {
+			event := traceEvent{
+				Name:  streamLabel(stream),
+				Cat:   "chunk",
+				Phase: "i",
+				Ts:    traceTime(offset),
+				PID:   mainPID,
+				TID:   i + 1,
+				Scope: "t",
+				Args:  map[string]any{"bytes": len(chunks[offset]), "text": tracePreview(chunks[offset])},
+			}
+			if slices {
+				next := end
+				if j+1 < len(offsets) {
+					next = offsets[j+1]
+				}
+				event.Phase, event.Scope, event.Dur = "X", "", traceDuration(next-offset)
+			}
+			events = append(events, event)
+		}
+	}
+
+	// one counter event per offset with the totals of all streams, shown as a single stacked track
+	totals := make(map[string]any)
+	for _, stream := range streams {
+		totals[streamLabel(stream)] = 0
+	}
+	byteEvents := Events(record, streams...)
+	for i, event := range byteEvents {
+		totals[streamLabel(event.Stream)] = totals[streamLabel(event.Stream)].(int) + len(event.Data)
+		if i+1 < len(byteEvents) && byteEvents[i+1].Offset == event.Offset {
+			continue
+		}
+		args := make(map[string]any, len(totals))
+		for name, total := range totals {
+			args[name] = total
+		}
+		events = append(events, traceEvent{Name: "bytes", Phase: "C", Ts: traceTime(event.Offset), PID: mainPID, Args: args})
+	}
+
+	for _, marker := range record.Markers() {
+		events = append(events, traceEvent{Name: marker.Label, Cat: "marker", Phase: "i", Ts: traceTime(marker.Offset), PID: mainPID, Scope: "g"})
+	}
+
+	file := traceFile{TraceEvents: events, DisplayTimeUnit: "ms", OtherData: make(map[string]string)}
+	for key, value := range record.Metadata() {
+		// the processes are already shown as tracks
+		if !strings.HasPrefix(key, MetadataStraceProcessPrefix) {
+			file.OtherData[key] = value
+		}
+	}
+	if record.Command() != "" {
+		file.OtherData["command"] = record.Command()
+	}
+
+	return json.NewEncoder(w).Encode(file)
+}
+
+func traceMetadata(name string, pid int, tid int, value string) traceEvent {
+	return traceEvent{Name: name, Phase: "M", PID: pid, TID: tid, Args: map[string]any{"name": value}}
+}
+
+// traceTime returns the offset in microseconds.
+func traceTime(offset time.Duration) float64 {
+	return float64(offset) / float64(time.Microsecond)
+}
+
+func traceDuration(duration time.Duration) *float64 {
+	microseconds := traceTime(duration)
+	return &microseconds
+}
+
+// tracePreview returns the start of the chunk as text without escape sequences.
+func tracePreview(data []byte) string {
+	text := strings.ToValidUTF8(string(StripANSI(data)), "�")
+	if utf8.RuneCountInString(text) > tracePreviewLength {
+		text = string([]rune(text)[:tracePreviewLength]) + "…"
+	}
+	return text
+}
@@ -0,0 +1,150 @@
+package recmd
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ttyrec files are a sequence of frames, each a 12 byte header followed by the data:
+//
+//	sec (uint32) | usec (uint32) | len (uint32), little endian
+//
+// The time is the wall clock time of the frame. ttyrec only knows the terminal output, so frames are read
+// as stdout events and stdout and stderr events are written as frames.
+
+const (
+	ttyrecHeaderSize = 12
+	// ttyrecMaxFrame is the largest frame accepted, it protects against allocating huge buffers for corrupt files.
+	ttyrecMaxFrame = 16 * 1024 * 1024
+)
+
+// looksLikeTTYRec reports whether data starts with plausible ttyrec headers.
+// Text encodings never pass this test, as a valid usec field contains a zero byte.
+func looksLikeTTYRec(data []byte) bool {
+	if len(data) < ttyrecHeaderSize {
+		return false
+	}
+	for len(data) >= ttyrecHeaderSize {
+		usec := binary.LittleEndian.Uint32(data[4:8])
+		length := binary.LittleEndian.Uint32(data[8:12])
+		if usec >= 1000000 || length > ttyrecMaxFrame {
+			return false
+		}
+		if uint64(len(data)) < ttyrecHeaderSize+uint64(length) {
+			break
+		}
+		data = data[ttyrecHeaderSize+length:]
+	}
+	return true
+}
+
+// EncodeTTYRec writes the stdout and stderr events of the record as ttyrec frames.
+// The frame times start at the start time of the record or the unix epoch if it has none.
+// Stdin and markers are dropped and stderr is mixed into the output, see TTYRecLosses.
+func EncodeTTYRec(w io.Writer, record Record) error {
+	start := time.Unix(0, 0)
+	if startTime, err := time.Parse(time.RFC3339, record.Metadata()[MetadataStartTime]); err == nil {
+		start = startTime
+	}
+
+	buffered := bufio.NewWriter(w)
+	header := make([]byte, ttyrecHeaderSize)
+	for _, event := range Events(record, StreamOut, StreamErr) {
+		if len(event.Data) == 0 {
+			continue
+		}
+		at := start.Add(event.Offset)
+		binary.LittleEndian.PutUint32(header[0:4], uint32(at.Unix()))
+		binary.LittleEndian.PutUint32(header[4:8], uint32(at.Nanosecond()/1000))
+		binary.LittleEndian.PutUint32(header[8:12], uint32(len(event.Data)))
+
+		_, err := buffered.Write(header)
+		if err != nil {
+			return err
+		}
+		_, err = buffered.Write(event.Data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return buffered.Flush()
+}
+
+// TTYRecLosses lists what of the record is lost when it is written as ttyrec file, empty if nothing is.
+func TTYRecLosses(record Record) []string {
+	var losses []string
+	if len(record.StdIn()) > 0 {
+		losses = append(losses, "stdin")
+	}
+	if len(record.StdErr()) > 0 {
+		losses = append(losses, "stderr as a separate stream")
+	}
+	if markers := len(record.Markers()); markers > 0 {
+		losses = append(losses, fmt.Sprintf("%d markers", markers))
+	}
+	return losses
+}
+
+// DecodeTTYRec reads a ttyrec file into a ByteRecord with the frames as stdout events.
+// Offsets are relative to the first frame, whose time is stored as start time.
+func DecodeTTYRec(r io.Reader) (Record, error) {
+	record := &ByteRecord{
+		JsonFormat: FormatBase64,
+		Out:        make(map[time.Duration][]byte),
+		In:         make(map[time.Duration][]byte),
+		Err:        make(map[time.Duration][]byte),
+	}
+
+	buffered := bufio.NewReader(r)
+	header := make([]byte, ttyrecHeaderSize)
+	var start time.Time
+	var previous time.Duration
+	for frame := 0; ; frame++ {
+		_, err := io.ReadFull(buffered, header)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ttyrec: frame %d: truncated header", frame)
+		}
+
+		sec := binary.LittleEndian.Uint32(header[0:4])
+		usec := binary.LittleEndian.Uint32(header[4:8])
+		length := binary.LittleEndian.Uint32(header[8:12])
+		if usec >= 1000000 {
+			return nil, fmt.Errorf("ttyrec: frame %d: invalid usec %d", frame, usec)
+		}
+		if length > ttyrecMaxFrame {
+			return nil, fmt.Errorf("ttyrec: frame %d: length %d too large", frame, length)
+		}
+
+		data := make([]byte, length)
+		_, err = io.ReadFull(buffered, data)
+		if err != nil {
+			return nil, fmt.Errorf("ttyrec: frame %d: truncated data", frame)
+		}
+
+		at := time.Unix(int64(sec), int64(usec)*1000)
+		if frame == 0 {
+			start = at
+			// files written from records without start time begin at the unix epoch
+			if sec != 0 {
+				record.SetMetadata(MetadataStartTime, start.Format(time.RFC3339))
+			}
+		}
+
+		offset := at.Sub(start)
+		if offset < previous {
+			// a clock going backwards would reorder the output, keep the frame at the previous offset
+			offset = previous
+		}
+		previous = offset
+		appendChunk(record.Out, offset, data)
+	}
+
+	return record, nil
+}
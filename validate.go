@@ -0,0 +1,361 @@
+package recmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Severity of a validation problem.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Problem is a single finding of a validation.
+type Problem struct {
+	// Path is a json path like $.out["1234"] or the line of an ndjson record like line 3.
+	Path     string   `json:"path"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s: %s", p.Severity, p.Path, p.Message)
+}
+
+// HasErrors reports whether any of the problems is an error.
+func HasErrors(problems []Problem) bool {
+	for _, problem := range problems {
+		if problem.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateData checks a (decompressed) record of any encoding and reports all problems found.
+// Json and ndjson records are checked field by field, other encodings by decoding them.
+func ValidateData(data []byte) []Problem {
+	encoding, err := DetectEncoding(bufio.NewReader(bytes.NewReader(data)))
+	if err != nil {
+		return []Problem{{Path: "$", Severity: SeverityError, Message: err.Error()}}
+	}
+
+	switch encoding {
+	case EncodingJSON:
+		return ValidateJSON(data)
+	case EncodingNDJSON:
+		return ValidateNDJSON(data)
+	case EncodingBinary:
+		_, err = DecodeBinary(bytes.NewReader(data))
+	case EncodingYAML:
+		_, err = DecodeYAML(bytes.NewReader(data))
+	case EncodingTTYRec:
+		_, err = DecodeTTYRec(bytes.NewReader(data))
+	}
+	if err != nil {
+		return []Problem{{Path: "$", Severity: SeverityError, Message: err.Error()}}
+	}
+	return nil
+}
+
+// ValidateJSON checks a json record and reports all problems found.
+func ValidateJSON(data []byte) []Problem {
+	v := &validator{}
+
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		v.errorf("$", "not a json object: %s", err)
+		return v.problems
+	}
+
+	format := FormatBase64
+	if raw, ok := fields["format"]; !ok {
+		v.warnf("$.format", "missing, treated as %s", FormatBase64)
+	} else {
+		var value string
+		if json.Unmarshal(raw, &value) != nil {
+			v.errorf("$.format", "expected a string")
+		} else {
+			switch RecordFormat(value) {
+			case FormatBase64, FormatString, FormatAuto:
+				format = RecordFormat(value)
+			default:
+				v.errorf("$.format", "unknown format %q, expected %s, %s or %s", value, FormatBase64, FormatString, FormatAuto)
+			}
+		}
+	}
+
+	if raw, ok := fields["command"]; !ok {
+		v.errorf("$.command", "missing")
+	} else {
+		var value string
+		if json.Unmarshal(raw, &value) != nil {
+			v.errorf("$.command", "expected a string")
+		}
+	}
+
+	if raw, ok := fields["exitcode"]; !ok {
+		v.warnf("$.exitcode", "missing, treated as 0")
+	} else {
+		var value int
+		if json.Unmarshal(raw, &value) != nil {
+			v.errorf("$.exitcode", "expected an integer")
+		}
+	}
+
+	for _, stream := range Streams {
+		path := "$." + string(stream)
+		raw, ok := fields[string(stream)]
+		if !ok {
+			v.errorf(path, "missing")
+			continue
+		}
+		v.validateChunks(path, raw, format)
+	}
+
+	if raw, ok := fields["markers"]; ok {
+		v.validateMarkers("$.markers", raw)
+	}
+
+	if raw, ok := fields["metadata"]; ok {
+		var value map[string]string
+		if json.Unmarshal(raw, &value) != nil {
+			v.errorf("$.metadata", "expected an object of strings")
+		}
+	}
+
+	if raw, ok := fields["streams"]; ok {
+		var streams map[string]json.RawMessage
+		if json.Unmarshal(raw, &streams) != nil {
+			v.errorf("$.streams", "expected an object of streams")
+		}
+		for name, chunks := range streams {
+			v.validateChunks(fmt.Sprintf("$.streams[%q]", name), chunks, format)
+		}
+	}
+
+	for key := range fields {
+		switch key {
+		case "format", "command", "exitcode", "out", "in", "err", "markers", "metadata", "streams":
+		default:
+			v.warnf("$."+key, "unknown field")
+		}
+	}
+
+	return v.problems
+}
+
+// ValidateNDJSON checks every line of an ndjson record and reports all problems found.
+func ValidateNDJSON(data []byte) []Problem {
+	v := &validator{}
+
+	lines := bytes.Split(data, []byte("\n"))
+	sawHeader, sawTrailer := false, false
+	for i, line := range lines {
+		path := fmt.Sprintf("line %d", i+1)
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		if sawTrailer {
+			v.errorf(path, "content after the trailer")
+			break
+		}
+
+		var entry NDJSONEntry
+		err := json.Unmarshal(line, &entry)
+		if err != nil {
+			v.errorf(path, "invalid json: %s", err)
+			continue
+		}
+
+		if !sawHeader && entry.Type != EntryHeader {
+			v.errorf(path, "expected the header as first line")
+		}
+
+		switch entry.Type {
+		case EntryHeader:
+			if sawHeader {
+				v.errorf(path, "duplicate header")
+			}
+			sawHeader = true
+			if entry.Version > NDJSONVersion {
+				v.errorf(path, "unsupported version %d", entry.Version)
+			}
+		case EntryEvent:
+			if streamOrder(entry.Stream) == len(Streams) {
+				v.errorf(path, "unknown stream %q", entry.Stream)
+			}
+			if entry.Offset < 0 {
+				v.errorf(path, "negative offset")
+			}
+		case EntryMarker:
+			if entry.Offset < 0 {
+				v.errorf(path, "negative offset")
+			}
+		case EntryTrailer:
+			sawTrailer = true
+			if entry.ExitCode == nil {
+				v.warnf(path, "missing exitcode, treated as 0")
+			}
+		default:
+			v.errorf(path, "unknown type %q", entry.Type)
+		}
+	}
+
+	if !sawHeader {
+		v.errorf("line 1", "missing header")
+	}
+	if !sawTrailer {
+		v.warnf(fmt.Sprintf("line %d", len(lines)), "missing trailer, the record is incomplete")
+	}
+
+	return v.problems
+}
+
+type validator struct {
+	problems []Problem
+}
+
+func (v *validator) errorf(path string, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{Path: path, Severity: SeverityError, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warnf(path string, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{Path: path, Severity: SeverityWarning, Message: fmt.Sprintf(format, args...)})
+}
+
+// validateChunks checks the offsets and chunks of a stream, reading the object token by token
+// to find duplicate offsets which json.Unmarshal would silently merge.
+func (v *validator) validateChunks(path string, raw json.RawMessage, format RecordFormat) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	token, err := decoder.Token()
+	if err != nil || token != json.Delim('{') {
+		v.errorf(path, "expected an object of offsets to chunks")
+		return
+	}
+
+	seen := make(map[int64]string)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			v.errorf(path, "invalid json: %s", err)
+			return
+		}
+		key := token.(string)
+		chunkPath := fmt.Sprintf("%s[%q]", path, key)
+
+		var value json.RawMessage
+		err = decoder.Decode(&value)
+		if err != nil {
+			v.errorf(chunkPath, "invalid json: %s", err)
+			return
+		}
+
+		offset, err := strconv.ParseInt(key, 10, 64)
+		switch {
+		case err != nil:
+			v.errorf(chunkPath, "offset is not an integer number of nanoseconds")
+		case offset < 0:
+			v.errorf(chunkPath, "negative offset")
+		default:
+			if previous, ok := seen[offset]; ok {
+				v.errorf(chunkPath, "duplicate offset, already used by %q", previous)
+			}
+			seen[offset] = key
+		}
+
+		v.validateChunk(chunkPath, value, format)
+	}
+}
+
+func (v *validator) validateChunk(path string, raw json.RawMessage, format RecordFormat) {
+	var text string
+	isString := json.Unmarshal(raw, &text) == nil
+
+	switch format {
+	case FormatBase64:
+		if !isString {
+			v.errorf(path, "expected a base64 string")
+			return
+		}
+		if _, err := base64.StdEncoding.DecodeString(text); err != nil {
+			v.errorf(path, "invalid base64: %s", err)
+		}
+	case FormatString:
+		if !isString {
+			v.errorf(path, "expected a string")
+			return
+		}
+		if strings.ContainsRune(text, utf8.RuneError) {
+			v.warnf(path, "contains replacement characters, the original bytes may be lost")
+		}
+	case FormatAuto:
+		if isString {
+			return
+		}
+		var binary map[string]json.RawMessage
+		if json.Unmarshal(raw, &binary) != nil || len(binary) != 1 || binary["base64"] == nil {
+			v.errorf(path, `expected a string or {"base64": "..."}`)
+			return
+		}
+		v.validateChunk(path+".base64", binary["base64"], FormatBase64)
+	}
+}
+
+func (v *validator) validateMarkers(path string, raw json.RawMessage) {
+	var markers []map[string]json.RawMessage
+	if json.Unmarshal(raw, &markers) != nil {
+		v.errorf(path, "expected an array of markers")
+		return
+	}
+
+	for i, marker := range markers {
+		markerPath := fmt.Sprintf("%s[%d]", path, i)
+
+		var offset int64
+		if value, ok := marker["offset"]; !ok {
+			v.errorf(markerPath+".offset", "missing")
+		} else if json.Unmarshal(value, &offset) != nil {
+			v.errorf(markerPath+".offset", "expected an integer number of nanoseconds")
+		} else if offset < 0 {
+			v.errorf(markerPath+".offset", "negative offset")
+		}
+
+		var label string
+		if value, ok := marker["label"]; !ok {
+			v.errorf(markerPath+".label", "missing")
+		} else if json.Unmarshal(value, &label) != nil {
+			v.errorf(markerPath+".label", "expected a string")
+		}
+	}
+}
+
+// ValidateReader reads, decrypts and decompresses a record and validates it.
+func ValidateReader(r io.Reader, options ...LoadOption) ([]Problem, error) {
+	decompressed, err := Open(r, options...)
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := decompressed.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	data, err := io.ReadAll(decompressed)
+	if err != nil {
+		return nil, err
+	}
+
+	return ValidateData(data), nil
+}
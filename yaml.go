@@ -0,0 +1,258 @@
+package recmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlRecord is the layout of a yaml record:
+//
+//	command: /usr/bin/echo hi
+//	exitcode: 0
+//	metadata:
+//	  start_time: "2023-07-10T17:16:24+02:00"
+//	markers:
+//	  - at: 1.250s
+//	    label: done
+//	events:
+//	  - at: 0.001s
+//	    stream: out
+//	    text: |
+//	      hi
+//	  - at: 0.002s
+//	    stream: err
+//	    base64: //5s
+//
+// Events have to be sorted by offset, chunks which are not valid UTF-8 are stored as base64.
+type yamlRecord struct {
+	Command  string            `yaml:"command"`
+	ExitCode int               `yaml:"exitcode"`
+	Metadata map[string]string `yaml:"metadata,omitempty"`
+	Markers  []yamlMarker      `yaml:"markers,omitempty"`
+	Events   []yamlEvent       `yaml:"events"`
+}
+
+type yamlMarker struct {
+	At    string `yaml:"at"`
+	Label string `yaml:"label"`
+
+	line int
+}
+
+type yamlEvent struct {
+	At     string    `yaml:"at"`
+	Stream Stream    `yaml:"stream"`
+	Text   *yamlText `yaml:"text,omitempty"`
+	Base64 *string   `yaml:"base64,omitempty"`
+
+	line int
+}
+
+// yamlText is the text of an event, multi-line texts are written as literal block scalars where possible.
+type yamlText string
+
+func (t yamlText) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: string(t)}
+	if strings.Contains(node.Value, "\n") {
+		// other multi-line texts are quoted, the encoder would write broken block scalars for texts starting with whitespace
+		node.Style = yaml.DoubleQuotedStyle
+		if blockSafe(node.Value) {
+			node.Style = yaml.LiteralStyle
+		}
+	}
+	return node, nil
+}
+
+// blockSafe reports whether the text can be written as literal block scalar.
+func blockSafe(text string) bool {
+	if strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t") || strings.HasPrefix(text, "\n") {
+		return false
+	}
+	for _, r := range text {
+		if r != '\n' && r != '\t' && (r < ' ' || r == 0x7f || r == 0xfeff) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *yamlMarker) UnmarshalYAML(node *yaml.Node) error {
+	type plain yamlMarker
+	m.line = node.Line
+	err := checkFields(node, "at", "label")
+	if err != nil {
+		return err
+	}
+	return node.Decode((*plain)(m))
+}
+
+func (e *yamlEvent) UnmarshalYAML(node *yaml.Node) error {
+	type plain yamlEvent
+	e.line = node.Line
+	err := checkFields(node, "at", "stream", "text", "base64")
+	if err != nil {
+		return err
+	}
+	return node.Decode((*plain)(e))
+}
+
+// checkFields rejects mappings with other keys than the given ones.
+func checkFields(node *yaml.Node, fields ...string) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("yaml: line %d: expected a mapping", node.Line)
+	}
+
+	for i := 0; i < len(node.Content); i += 2 {
+		key := node.Content[i]
+		known := false
+		for _, field := range fields {
+			if key.Value == field {
+				known = true
+			}
+		}
+		if !known {
+			return fmt.Errorf("yaml: line %d: unknown field %q, expected one of %s", key.Line, key.Value, strings.Join(fields, ", "))
+		}
+	}
+
+	return nil
+}
+
+// FormatOffset formats an offset as seconds with at least millisecond precision, like 1.250s.
+func FormatOffset(offset time.Duration) string {
+	sign := ""
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	fraction := strings.TrimRight(fmt.Sprintf("%09d", offset%time.Second), "0")
+	for len(fraction) < 3 {
+		fraction += "0"
+	}
+	return fmt.Sprintf("%s%d.%ss", sign, offset/time.Second, fraction)
+}
+
+// EncodeYAML writes the record as yaml to w.
+func EncodeYAML(w io.Writer, record Record) error {
+	document := yamlRecord{
+		Command:  record.Command(),
+		ExitCode: record.ExitCode(),
+		Metadata: record.Metadata(),
+		Events:   []yamlEvent{},
+	}
+
+	for _, marker := range record.Markers() {
+		document.Markers = append(document.Markers, yamlMarker{At: FormatOffset(marker.Offset), Label: marker.Label})
+	}
+
+	for _, event := range Events(record) {
+		yamlEvent := yamlEvent{At: FormatOffset(event.Offset), Stream: event.Stream}
+		if utf8.Valid(event.Data) {
+			text := yamlText(event.Data)
+			yamlEvent.Text = &text
+		} else {
+			encoded := base64.StdEncoding.EncodeToString(event.Data)
+			yamlEvent.Base64 = &encoded
+		}
+		document.Events = append(document.Events, yamlEvent)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	err := encoder.Encode(document)
+	if err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// DecodeYAML reads a yaml record into a ByteRecord and validates it.
+// Errors contain the line of the offending entry.
+func DecodeYAML(r io.Reader) (Record, error) {
+	var document yamlRecord
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	err := decoder.Decode(&document)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &ByteRecord{
+		JsonFormat: FormatBase64,
+		Cmd:        document.Command,
+		ExitC:      document.ExitCode,
+		Out:        make(map[time.Duration][]byte),
+		In:         make(map[time.Duration][]byte),
+		Err:        make(map[time.Duration][]byte),
+		Meta:       document.Metadata,
+	}
+	targets := map[Stream]map[time.Duration][]byte{
+		StreamOut: record.Out,
+		StreamIn:  record.In,
+		StreamErr: record.Err,
+	}
+
+	for _, marker := range document.Markers {
+		offset, err := parseYAMLOffset(marker.At, marker.line)
+		if err != nil {
+			return nil, err
+		}
+		record.AddMarker(Marker{Offset: offset, Label: marker.Label})
+	}
+
+	previous := time.Duration(0)
+	for _, event := range document.Events {
+		offset, err := parseYAMLOffset(event.At, event.line)
+		if err != nil {
+			return nil, err
+		}
+		if offset < previous {
+			return nil, fmt.Errorf("yaml: line %d: offset %s is before the offset %s of the previous event", event.line, FormatOffset(offset), FormatOffset(previous))
+		}
+		previous = offset
+
+		target, ok := targets[event.Stream]
+		if !ok {
+			return nil, fmt.Errorf("yaml: line %d: unknown stream %q, expected out, in or err", event.line, event.Stream)
+		}
+
+		var data []byte
+		switch {
+		case event.Text != nil && event.Base64 != nil:
+			return nil, fmt.Errorf("yaml: line %d: event has both text and base64", event.line)
+		case event.Text != nil:
+			data = []byte(*event.Text)
+		case event.Base64 != nil:
+			data, err = base64.StdEncoding.DecodeString(*event.Base64)
+			if err != nil {
+				return nil, fmt.Errorf("yaml: line %d: invalid base64: %w", event.line, err)
+			}
+		default:
+			return nil, fmt.Errorf("yaml: line %d: event has neither text nor base64", event.line)
+		}
+
+		appendChunk(target, offset, data)
+	}
+
+	return record, nil
+}
+
+func parseYAMLOffset(value string, line int) (time.Duration, error) {
+	if value == "" {
+		return 0, fmt.Errorf("yaml: line %d: missing offset 'at'", line)
+	}
+	offset, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("yaml: line %d: invalid offset %q, expected a duration like 1.250s", line, value)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("yaml: line %d: negative offset %q", line, value)
+	}
+	return offset, nil
+}